@@ -0,0 +1,197 @@
+// Package cluster replicates share deduplication and job/extranonce
+// state across pool nodes via Raft, so a pool that runs on more than
+// one node can validate submissions consistently regardless of which
+// node a miner happens to be connected to.
+package cluster
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/hashicorp/raft"
+	boltdb "github.com/hashicorp/raft-boltdb"
+
+	"pool/core"
+	"pool/logs"
+)
+
+var errShareDuplicate = errors.New("cluster: share already recorded")
+
+// Config mirrors the cfg.Cluster block loaded by config.LoadConfig.
+type Config struct {
+	NodeID   string
+	BindAddr string
+	DataDir  string
+	Peers    []string // host:port of other known nodes; only checked for emptiness: when non-empty, Bootstrap does not bootstrap a cluster of its own, and the addresses are not dialed
+	ShareTTL time.Duration
+}
+
+// Store is a Raft-backed core.ShareStore: Save replicates through the
+// Raft log, Exists reads from this node's local FSM state.
+type Store struct {
+	raft *raft.Raft
+	fsm  *fsm
+	cfg  Config
+}
+
+// Bootstrap starts (or rejoins) a Raft node using BoltDB-backed log and
+// stable stores, as recommended for small clusters by hashicorp/raft.
+func Bootstrap(cfg Config) (*Store, error) {
+	if cfg.NodeID == "" || cfg.BindAddr == "" {
+		return nil, errors.New("cluster: NodeID and BindAddr are required")
+	}
+	if cfg.ShareTTL == 0 {
+		cfg.ShareTTL = 45 * time.Second
+	}
+	if err := os.MkdirAll(cfg.DataDir, 0o755); err != nil {
+		return nil, fmt.Errorf("cluster: create data dir: %w", err)
+	}
+
+	raftCfg := raft.DefaultConfig()
+	raftCfg.LocalID = raft.ServerID(cfg.NodeID)
+
+	addr, err := net.ResolveTCPAddr("tcp", cfg.BindAddr)
+	if err != nil {
+		return nil, fmt.Errorf("cluster: resolve bind addr: %w", err)
+	}
+	transport, err := raft.NewTCPTransport(cfg.BindAddr, addr, 3, 10*time.Second, os.Stderr)
+	if err != nil {
+		return nil, fmt.Errorf("cluster: new transport: %w", err)
+	}
+
+	snapshots, err := raft.NewFileSnapshotStore(cfg.DataDir, 2, os.Stderr)
+	if err != nil {
+		return nil, fmt.Errorf("cluster: new snapshot store: %w", err)
+	}
+
+	logStore, err := boltdb.NewBoltStore(filepath.Join(cfg.DataDir, "raft-log.db"))
+	if err != nil {
+		return nil, fmt.Errorf("cluster: new log store: %w", err)
+	}
+	stableStore, err := boltdb.NewBoltStore(filepath.Join(cfg.DataDir, "raft-stable.db"))
+	if err != nil {
+		return nil, fmt.Errorf("cluster: new stable store: %w", err)
+	}
+
+	f := newFSM(cfg.ShareTTL)
+
+	r, err := raft.NewRaft(raftCfg, f, logStore, stableStore, snapshots, transport)
+	if err != nil {
+		return nil, fmt.Errorf("cluster: new raft node: %w", err)
+	}
+
+	hasState, err := raft.HasExistingState(logStore, stableStore, snapshots)
+	if err != nil {
+		return nil, fmt.Errorf("cluster: check existing state: %w", err)
+	}
+	if !hasState && len(cfg.Peers) == 0 {
+		// Single-node bootstrap: this node is its own cluster.
+		bootstrapCfg := raft.Configuration{
+			Servers: []raft.Server{{ID: raftCfg.LocalID, Address: transport.LocalAddr()}},
+		}
+		if err := r.BootstrapCluster(bootstrapCfg).Error(); err != nil {
+			return nil, fmt.Errorf("cluster: bootstrap: %w", err)
+		}
+	}
+
+	store := &Store{raft: r, fsm: f, cfg: cfg}
+
+	go store.logLeaderChanges()
+
+	return store, nil
+}
+
+func (s *Store) logLeaderChanges() {
+	for isLeader := range s.raft.LeaderCh() {
+		logs.WithFields(map[string]interface{}{
+			"node_id":   s.cfg.NodeID,
+			"is_leader": isLeader,
+		}).Info("Raft leadership changed")
+	}
+}
+
+// Save replicates the share through Raft so every node's local FSM
+// converges on the same dedup set.
+func (s *Store) Save(share core.Share) error {
+	cmd := command{Kind: cmdSaveShare, Share: &share}
+	return s.apply(cmd)
+}
+
+// Exists is served from this node's local FSM for low latency; it may
+// briefly lag the leader on a follower that hasn't caught up yet.
+func (s *Store) Exists(shareID string) (bool, error) {
+	return s.fsm.exists(shareID), nil
+}
+
+// SaveIfAbsent applies cmdSaveShare through Raft and reports whether this
+// call's share is the one that won. The FSM already rejects a duplicate
+// ID atomically under its own lock (see fsm.Apply), so unlike the
+// Postgres store this needs no retry loop: Raft
+// serializes every Apply through the leader, which is already the single
+// point of agreement the compare-and-swap model wants.
+func (s *Store) SaveIfAbsent(share core.Share) (bool, error) {
+	cmd := command{Kind: cmdSaveShare, Share: &share}
+	if err := s.apply(cmd); err != nil {
+		if errors.Is(err, errShareDuplicate) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// AdvanceJob replicates a new job/extranonce counter so any node can
+// validate submissions against the current job regardless of which
+// node originally created it.
+func (s *Store) AdvanceJob(jobID string, blockHeight int, extranonceCounter uint64) error {
+	cmd := command{Kind: cmdAdvanceJob, Job: &jobStateUpdate{
+		JobID:         jobID,
+		BlockHeight:   blockHeight,
+		ExtranonceCtr: extranonceCounter,
+	}}
+	return s.apply(cmd)
+}
+
+func (s *Store) apply(cmd command) error {
+	if s.raft.State() != raft.Leader {
+		return fmt.Errorf("cluster: not leader, forward to %s", s.LeaderAddr())
+	}
+
+	payload, err := json.Marshal(cmd)
+	if err != nil {
+		return fmt.Errorf("cluster: encode command: %w", err)
+	}
+
+	future := s.raft.Apply(payload, 5*time.Second)
+	if err := future.Error(); err != nil {
+		return fmt.Errorf("cluster: raft apply: %w", err)
+	}
+	if respErr, ok := future.Response().(error); ok && respErr != nil {
+		return respErr
+	}
+	return nil
+}
+
+// IsLeader reports whether this node currently holds Raft leadership.
+// HTTP/Stratum front-ends use this to gate writes that must go through
+// the leader.
+func (s *Store) IsLeader() bool {
+	return s.raft.State() == raft.Leader
+}
+
+// LeaderAddr returns the currently known leader's transport address, or
+// an empty string if none is known yet.
+func (s *Store) LeaderAddr() string {
+	addr, _ := s.raft.LeaderWithID()
+	return string(addr)
+}
+
+// Shutdown gracefully leaves the Raft cluster.
+func (s *Store) Shutdown() error {
+	return s.raft.Shutdown().Error()
+}
@@ -0,0 +1,182 @@
+package cluster
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/raft"
+
+	"pool/core"
+	"pool/logs"
+)
+
+// commandKind discriminates the log entries applied to the FSM.
+type commandKind string
+
+const (
+	cmdSaveShare  commandKind = "save_share"
+	cmdAdvanceJob commandKind = "advance_job"
+)
+
+type command struct {
+	Kind  commandKind     `json:"kind"`
+	Share *core.Share     `json:"share,omitempty"`
+	Job   *jobStateUpdate `json:"job,omitempty"`
+}
+
+// jobStateUpdate replicates the subset of job/extranonce state any node
+// needs in order to validate submissions regardless of which node a
+// miner happens to be connected to.
+type jobStateUpdate struct {
+	JobID         string `json:"job_id"`
+	BlockHeight   int    `json:"block_height"`
+	ExtranonceCtr uint64 `json:"extranonce_counter"`
+}
+
+// fsm is the Raft finite state machine backing the replicated share
+// cache and job state. Shares applied more than shareTTL ago read as
+// absent through exists and are dropped by Restore; otherwise applied
+// share IDs stay in memory, and Apply rejects a repeated ID regardless
+// of its age.
+type fsm struct {
+	mu sync.RWMutex
+
+	shareTTL          time.Duration
+	shares            map[string]time.Time // shareID -> applied-at, for TTL eviction
+	jobs              map[string]jobStateUpdate
+	extranonceCounter uint64
+}
+
+func newFSM(shareTTL time.Duration) *fsm {
+	return &fsm{
+		shareTTL: shareTTL,
+		shares:   make(map[string]time.Time),
+		jobs:     make(map[string]jobStateUpdate),
+	}
+}
+
+func (f *fsm) Apply(log *raft.Log) interface{} {
+	var cmd command
+	if err := json.Unmarshal(log.Data, &cmd); err != nil {
+		logs.WithFields(map[string]interface{}{"error": err.Error()}).Error("cluster: corrupt raft log entry")
+		return err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	switch cmd.Kind {
+	case cmdSaveShare:
+		if cmd.Share == nil {
+			return nil
+		}
+		if _, exists := f.shares[cmd.Share.ID]; exists {
+			return errShareDuplicate
+		}
+		f.shares[cmd.Share.ID] = time.Now()
+		return nil
+
+	case cmdAdvanceJob:
+		if cmd.Job == nil {
+			return nil
+		}
+		f.jobs[cmd.Job.JobID] = *cmd.Job
+		if cmd.Job.ExtranonceCtr > f.extranonceCounter {
+			f.extranonceCounter = cmd.Job.ExtranonceCtr
+		}
+		return nil
+	}
+	return nil
+}
+
+func (f *fsm) exists(shareID string) bool {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	appliedAt, ok := f.shares[shareID]
+	if !ok {
+		return false
+	}
+	return time.Since(appliedAt) <= f.shareTTL
+}
+
+// snapshotState is the on-disk representation written by Snapshot and
+// read back by Restore. A SchemaVersion field lets future fields get
+// added without breaking snapshots taken by older binaries.
+type snapshotState struct {
+	SchemaVersion     int                       `json:"schema_version"`
+	Shares            map[string]time.Time      `json:"shares"`
+	Jobs              map[string]jobStateUpdate `json:"jobs"`
+	ExtranonceCounter uint64                    `json:"extranonce_counter"`
+}
+
+const currentSnapshotSchema = 1
+
+func (f *fsm) Snapshot() (raft.FSMSnapshot, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	state := snapshotState{
+		SchemaVersion:     currentSnapshotSchema,
+		Shares:            cloneShares(f.shares),
+		Jobs:              cloneJobs(f.jobs),
+		ExtranonceCounter: f.extranonceCounter,
+	}
+	return &fsmSnapshot{state: state}, nil
+}
+
+func (f *fsm) Restore(rc io.ReadCloser) error {
+	defer rc.Close()
+
+	var state snapshotState
+	if err := json.NewDecoder(rc).Decode(&state); err != nil {
+		return err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	now := time.Now()
+	f.shares = make(map[string]time.Time, len(state.Shares))
+	for id, appliedAt := range state.Shares {
+		if now.Sub(appliedAt) > f.shareTTL {
+			continue // drop entries already past TTL, per restore contract
+		}
+		f.shares[id] = appliedAt
+	}
+	f.jobs = cloneJobs(state.Jobs)
+	f.extranonceCounter = state.ExtranonceCounter
+	return nil
+}
+
+type fsmSnapshot struct {
+	state snapshotState
+}
+
+func (s *fsmSnapshot) Persist(sink raft.SnapshotSink) error {
+	err := json.NewEncoder(sink).Encode(s.state)
+	if err != nil {
+		sink.Cancel()
+		return err
+	}
+	return sink.Close()
+}
+
+func (s *fsmSnapshot) Release() {}
+
+func cloneShares(in map[string]time.Time) map[string]time.Time {
+	out := make(map[string]time.Time, len(in))
+	for k, v := range in {
+		out[k] = v
+	}
+	return out
+}
+
+func cloneJobs(in map[string]jobStateUpdate) map[string]jobStateUpdate {
+	out := make(map[string]jobStateUpdate, len(in))
+	for k, v := range in {
+		out[k] = v
+	}
+	return out
+}
@@ -10,11 +10,18 @@ import (
 	"syscall"
 	"time"
 
+	"pool"
+	"pool/cluster"
 	"pool/config"
+	"pool/core"
+	poolstore "pool/database"
 	phttp "pool/http"
 	"pool/logs"
-	"pool/security"
-	"pool/smartcontract"
+	"pool/metrics"
+	"pool/payout"
+	"pool/stratum"
+	"pool/telemetry"
+	"validation_service/database"
 )
 
 func printAsciiBanner() {
@@ -52,6 +59,15 @@ func main() {
 		}).Fatal("Missing critical configuration")
 	}
 
+	cfgWatcher := config.NewWatcher(".env", cfg)
+	cfgWatchCtx, cfgWatchCancel := context.WithCancel(context.Background())
+	defer cfgWatchCancel()
+	if err := cfgWatcher.Start(cfgWatchCtx); err != nil {
+		logs.WithFields(map[string]interface{}{
+			"error": err.Error(),
+		}).Warn("Config hot-reload watcher disabled")
+	}
+
 	env := cfg.Env
 	if env == "" {
 		if e := os.Getenv("ENV"); e != "" {
@@ -71,51 +87,156 @@ func main() {
 
 	// Removed logging the full config to avoid leaking sensitive info
 
-	if err := security.LoadSecrets(cfg); err != nil {
+	shutdownTracing, err := telemetry.Init(context.Background(), *cfg)
+	if err != nil {
 		logs.WithFields(map[string]interface{}{
 			"error": err.Error(),
-		}).Fatal("Failed to initialize security module")
+		}).Fatal("Failed to initialize tracing")
 	}
+	defer func() {
+		if err := shutdownTracing(context.Background()); err != nil {
+			logs.WithFields(map[string]interface{}{
+				"error": err.Error(),
+			}).Error("Tracing shutdown failed")
+		}
+	}()
 
 	// Initialization of database connection, shareStore and smartcontract
 	// should be done securely without exposing sensitive data in logs.
 
-	// Example placeholder for connecting and using shareStore:
-	// db, err := database.Connect(cfg.Database.URL)
-	// if err != nil {
-	//	   logs.WithFields(map[string]interface{}{"error": err.Error()}).Fatal("Database connection failed")
-	// }
-	// defer db.Close()
-	// shareStore := database.NewPostgresShareStore(db)
+	if err := database.InitializePostgres(cfg.Database.URL); err != nil {
+		logs.WithFields(map[string]interface{}{
+			"error": err.Error(),
+			"dsn":   redactDSN(cfg.Database.URL),
+		}).Fatal("Failed to connect to Postgres")
+	}
+	defer database.ClosePostgres()
 
-	paymentEngine, err := smartcontract.Init(cfg)
-	if err != nil {
+	payoutLedger := payout.NewPostgresLedger(database.DB)
+	if err := payoutLedger.EnsureSchema(); err != nil {
 		logs.WithFields(map[string]interface{}{
 			"error": err.Error(),
-		}).Fatal("Smart contract initialization failed")
+		}).Fatal("Failed to initialize payouts table")
 	}
 
-	if rs, ok := paymentEngine.(interface {
-		SendReward(string, *big.Int) (string, error)
-	}); ok {
-		phttp.SetPaymentClient(rs)
+	// shareStore backs dedup, persistence, and balance locking for every
+	// submitted share; it's built here (not inside pool.Init) because it
+	// needs whichever backend is already connected above, and because a
+	// cluster deployment must finish joining Raft before the HTTP server
+	// starts accepting submissions against it.
+	var clusterStore *cluster.Store
+	var shareStore core.ShareStore
+	if cfg.Cluster.Enabled {
+		clusterStore, err = cluster.Bootstrap(cluster.Config{
+			NodeID:   cfg.Cluster.NodeID,
+			BindAddr: cfg.Cluster.BindAddr,
+			DataDir:  cfg.Cluster.DataDir,
+			Peers:    cfg.Cluster.Peers,
+		})
+		if err != nil {
+			logs.WithFields(map[string]interface{}{
+				"error": err.Error(),
+			}).Fatal("Failed to bootstrap Raft cluster")
+		}
+		defer clusterStore.Shutdown()
+		shareStore = clusterStore
 	} else {
+		shareStore = poolstore.NewPostgresPoolStore(&poolstore.Postgres{DB: database.DB})
+	}
+
+	container, err := pool.Init(context.Background(), cfg, shareStore)
+	if err != nil {
+		logs.WithFields(map[string]interface{}{
+			"error": err.Error(),
+		}).Fatal("Failed to bootstrap pool container")
+	}
+
+	if container.PaymentClient == nil {
 		logs.Warn("Payment engine doesn't expose SendReward; /test-payout will be limited")
 	}
 
-	// You can adjust pool creation according to your real implementation
-	// pool := core.NewPool(cfg, db, paymentEngine, shareStore)
+	threshold, ok := new(big.Int).SetString(cfg.Payout.ThresholdWei, 10)
+	if !ok {
+		threshold = big.NewInt(0)
+	}
+	payoutManager, err := payout.NewManager(payout.Config{
+		SchemeName:  cfg.Payout.Scheme,
+		PoolFee:     cfg.PoolParams.RewardDistributionCut,
+		Threshold:   threshold,
+		PPLNSWindow: cfg.Payout.PPLNSWindow,
+	}, payoutLedger, container.PaymentClient)
+	if err != nil {
+		logs.WithFields(map[string]interface{}{
+			"error": err.Error(),
+		}).Fatal("Failed to initialize payout scheme")
+	}
+	container.Payout = payoutManager
 
-	// Start pool logic if available, e.g., pool.Start(ctx)
+	cfgWatcher.OnReload(func(next *config.Config) {
+		nextThreshold, ok := new(big.Int).SetString(next.Payout.ThresholdWei, 10)
+		if !ok {
+			nextThreshold = big.NewInt(0)
+		}
+		if err := payoutManager.SetFeeAndThreshold(next.PoolParams.RewardDistributionCut, nextThreshold); err != nil {
+			logs.WithFields(map[string]interface{}{
+				"error": err.Error(),
+			}).Error("Rejected payout fee/threshold reload")
+		}
+	})
 
-	router := phttp.NewRouter(nil) // Pass your real pool instance here
-	server := phttp.NewServer(cfg, router)
+	router := phttp.NewRouter()
+	server := phttp.NewServer(cfg, cfgWatcher, router, container)
 
 	errChan := make(chan error, 1)
 	go func() {
 		errChan <- server.Start()
 	}()
 
+	var stratumServer *stratum.Server
+	stratumCtx, stratumCancel := context.WithCancel(context.Background())
+	defer stratumCancel()
+
+	if cfg.Stratum.Enabled {
+		jobManager := core.NewJobManager(30 * time.Second)
+
+		const jobSnapshotPath = "./data/jobs.snapshot"
+		if err := jobManager.LoadSnapshot(jobSnapshotPath); err != nil {
+			logs.Warnf("Failed to load job snapshot: %v", err)
+		}
+		jobManager.SnapshotEvery(30*time.Second, jobSnapshotPath)
+
+		processor := core.NewShareProcessor(nil, shareStore)
+		processor.OnAccepted = payoutManager.OnAccepted
+
+		stratumServer = stratum.NewServer(stratum.Config{
+			Addr:      cfg.Stratum.Addr,
+			V2Enabled: cfg.Stratum.V2Enabled,
+			V2Addr:    cfg.Stratum.V2Addr,
+		}, jobManager, processor)
+
+		difficulty := core.NewDifficultyController(
+			float64(cfg.PoolParams.MinDifficulty),
+			float64(cfg.PoolParams.MaxDifficulty),
+			10, // target shares/minute
+			30*time.Second,
+		)
+		difficulty.OnRetarget = func(workerID core.WorkerIdentifier, newDiff float64) {
+			metrics.RetargetCount.WithLabelValues(string(workerID)).Inc()
+			metrics.CurrentDiff.WithLabelValues(string(workerID)).Set(newDiff)
+			metrics.SharesPerMinute.WithLabelValues(string(workerID)).Set(difficulty.SharesPerMinute(workerID))
+			stratumServer.SetDifficulty(workerID, newDiff)
+		}
+		processor.Difficulty = difficulty
+
+		cfgWatcher.OnReload(func(next *config.Config) {
+			difficulty.SetBounds(float64(next.PoolParams.MinDifficulty), float64(next.PoolParams.MaxDifficulty))
+		})
+
+		go func() {
+			errChan <- stratumServer.ListenAndServe(stratumCtx)
+		}()
+	}
+
 	sig := make(chan os.Signal, 1)
 	signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
 
@@ -126,13 +247,22 @@ func main() {
 		if err != nil {
 			logs.WithFields(map[string]interface{}{
 				"error": err.Error(),
-			}).Error("HTTP server crashed unexpectedly")
+			}).Error("Server crashed unexpectedly")
 		}
 	}
 
 	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer shutdownCancel()
 
+	stratumCancel()
+	if stratumServer != nil {
+		if err := stratumServer.Shutdown(shutdownCtx); err != nil {
+			logs.WithFields(map[string]interface{}{
+				"error": err.Error(),
+			}).Error("Stratum shutdown failed")
+		}
+	}
+
 	if err := server.Shutdown(shutdownCtx); err != nil {
 		logs.WithFields(map[string]interface{}{
 			"error": err.Error(),
@@ -0,0 +1,151 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"sync"
+	"sync/atomic"
+	"syscall"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/joho/godotenv"
+
+	"pool/metrics"
+)
+
+// Watcher hot-reloads Config from an env file, on either a filesystem
+// change or a SIGHUP, and swaps it into an atomic.Pointer so readers
+// never observe a half-updated Config. Fields that must not change
+// after startup (server port, DB DSN) are rejected rather than applied.
+type Watcher struct {
+	path    string
+	current atomic.Pointer[Config]
+
+	mu        sync.Mutex
+	listeners []func(cfg *Config)
+}
+
+// NewWatcher wraps an already-loaded Config so callers can start reading
+// through w.Current() immediately, before Start has even begun watching.
+func NewWatcher(path string, initial *Config) *Watcher {
+	w := &Watcher{path: path}
+	w.current.Store(initial)
+	return w
+}
+
+// Current returns the most recently applied Config. Safe for concurrent
+// use; downstream consumers should call this per-request/per-use rather
+// than caching the pointer at construction time.
+func (w *Watcher) Current() *Config {
+	return w.current.Load()
+}
+
+// OnReload registers a callback invoked after a reload is accepted and
+// swapped in. Used by subsystems that hold internal state derived from
+// config (the difficulty controller's bounds, the payout scheme's fee)
+// rather than reading Current() fresh on every operation.
+func (w *Watcher) OnReload(fn func(cfg *Config)) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.listeners = append(w.listeners, fn)
+}
+
+// Start watches w.path for writes and listens for SIGHUP, reloading the
+// config on either. It runs until ctx is cancelled.
+func (w *Watcher) Start(ctx context.Context) error {
+	fsw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("config: create fsnotify watcher: %w", err)
+	}
+
+	if w.path != "" {
+		if err := fsw.Add(w.path); err != nil {
+			fsw.Close()
+			return fmt.Errorf("config: watch %s: %w", w.path, err)
+		}
+	}
+
+	sighup := make(chan os.Signal, 1)
+	signal.Notify(sighup, syscall.SIGHUP)
+
+	go func() {
+		defer fsw.Close()
+		defer signal.Stop(sighup)
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+
+			case sig := <-sighup:
+				log.Printf("config: reload triggered by %s", sig)
+				w.reload()
+
+			case event, ok := <-fsw.Events:
+				if !ok {
+					return
+				}
+				if event.Op&(fsnotify.Write|fsnotify.Create) == 0 {
+					continue
+				}
+				log.Printf("config: reload triggered by file change: %s", event.Name)
+				w.reload()
+
+			case err, ok := <-fsw.Errors:
+				if !ok {
+					return
+				}
+				log.Printf("config: fsnotify error: %v", err)
+			}
+		}
+	}()
+
+	return nil
+}
+
+func (w *Watcher) reload() {
+	next, err := w.rebuild()
+	if err != nil {
+		metrics.ConfigReloadTotal.WithLabelValues("failure").Inc()
+		log.Printf("config: reload rejected: %v", err)
+		return
+	}
+
+	metrics.ConfigReloadTotal.WithLabelValues("success").Inc()
+
+	w.mu.Lock()
+	listeners := append([]func(cfg *Config){}, w.listeners...)
+	w.mu.Unlock()
+	for _, l := range listeners {
+		l(next)
+	}
+}
+
+func (w *Watcher) rebuild() (*Config, error) {
+	if w.path != "" {
+		if err := godotenv.Overload(w.path); err != nil {
+			return nil, fmt.Errorf("reload %s: %w", w.path, err)
+		}
+	}
+
+	next, err := buildConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	prev := w.current.Load()
+	if prev != nil {
+		if next.Server.Port != prev.Server.Port || next.Server.Host != prev.Server.Host {
+			return nil, fmt.Errorf("SERVER_HOST/SERVER_PORT cannot change without a restart")
+		}
+		if next.Database.URL != prev.Database.URL {
+			return nil, fmt.Errorf("DATABASE_URL cannot change without a restart")
+		}
+	}
+
+	w.current.Store(next)
+	return next, nil
+}
@@ -0,0 +1,173 @@
+// Package pool wires together the config, security, and pool
+// subsystems behind a single Container, constructed once at startup by
+// Init and threaded through request handling via context instead of
+// package-level globals.
+package pool
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"net/http"
+
+	"pool/config"
+	"pool/core"
+	"pool/metrics"
+	"pool/payout"
+	"pool/security"
+	"pool/smartcontract"
+)
+
+// RewardSender is the capability a payment backend needs to back
+// payout endpoints; smartcontract.PaymentClient and
+// smartcontract.MockPaymentClient both satisfy it, as would any real
+// smartcontract.Engine that also exposes SendReward.
+type RewardSender interface {
+	SendReward(to string, amount *big.Int) (string, error)
+}
+
+// Container holds strongly-typed handles to every subsystem a request
+// handler might need, built once by Init. Handlers pull it from
+// context via FromContext rather than reaching for package-level
+// globals.
+type Container struct {
+	Config *config.Config
+
+	Pool       *core.Pool
+	ShareStore core.ShareStore
+
+	FraudDetector *security.Inspector
+
+	// PaymentClient is nil when the configured smartcontract.Engine
+	// doesn't expose SendReward (e.g. the default MockEngine); routes
+	// that need it should check for nil before use.
+	PaymentClient RewardSender
+
+	// Payout is nil until main.go constructs the scheme manager and
+	// assigns it in, since it depends on cfg.Payout and the Postgres
+	// ledger connection, neither of which Init touches; routes that
+	// serve payout data should check for nil before use.
+	Payout *payout.Manager
+
+	// Consensus is nil when PoolParams.ValidatorEndpoints is empty, in
+	// which case share submission stays local-only; handlers should check
+	// for nil before assigning it onto a core.ShareProcessor.
+	Consensus core.ConsensusValidator
+
+	// Auth issues and verifies the JWTs that gate authenticated routes,
+	// and rotates its own signing key on cfg.Auth.KeyRotationInterval.
+	Auth *security.JWTAuthenticator
+
+	// Metrics serves the Prometheus registry this Container's
+	// subsystems register their collectors against.
+	Metrics http.Handler
+}
+
+type ctxKey struct{}
+
+// NewContext returns a copy of ctx carrying c, retrievable later via
+// FromContext.
+func NewContext(ctx context.Context, c *Container) context.Context {
+	return context.WithValue(ctx, ctxKey{}, c)
+}
+
+// FromContext retrieves the Container stored by NewContext, if any.
+func FromContext(ctx context.Context) (*Container, bool) {
+	c, ok := ctx.Value(ctxKey{}).(*Container)
+	return c, ok
+}
+
+// Init constructs every pool subsystem from cfg and validates
+// cross-cutting invariants that would otherwise only surface at first
+// request (an unauthenticated production deployment, a payout endpoint
+// with no wired payment client) before the server starts accepting
+// traffic. shareStore backs both share dedup and, when it also
+// implements pool/http's ShareSaver/BalanceStore, persistence and
+// balance locking; it's built by the caller (Postgres- or
+// cluster-backed per cfg.Cluster.Enabled) rather than by Init, since
+// Init has no connection of its own to either backend. A nil
+// shareStore is accepted for tests and degrades share handling to
+// local-only: dedup, persistence, and balance locks all fail closed
+// instead of panicking (see DefaultShareValidator.ValidateShare and
+// ShareProcessor.process).
+func Init(ctx context.Context, cfg *config.Config, shareStore core.ShareStore) (*Container, error) {
+	if cfg == nil {
+		return nil, fmt.Errorf("pool: cannot initialize with a nil config")
+	}
+
+	if err := security.LoadSecrets(cfg); err != nil {
+		return nil, fmt.Errorf("pool: security init failed: %w", err)
+	}
+
+	metrics.InitRegistry()
+
+	paymentEngine, err := smartcontract.Init(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("pool: smart contract init failed: %w", err)
+	}
+
+	var rewardSender RewardSender
+	if rs, ok := paymentEngine.(RewardSender); ok {
+		rewardSender = rs
+	}
+
+	var consensus core.ConsensusValidator
+	if len(cfg.PoolParams.ValidatorEndpoints) > 0 {
+		v, err := core.NewHTTPConsensusValidator(
+			cfg.PoolParams.ValidatorEndpoints,
+			cfg.PoolParams.MinValidatorSubmit,
+			cfg.PoolParams.MinValidatorConfirmation,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("pool: consensus validator init failed: %w", err)
+		}
+		consensus = v
+	}
+
+	auth, err := security.NewJWTAuthenticator(
+		cfg.Auth.Issuer,
+		cfg.Auth.Audience,
+		cfg.Auth.TokenTTL,
+		cfg.Auth.MaxTokenLifetime,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("pool: auth init failed: %w", err)
+	}
+	auth.StartRotation(cfg.Auth.KeyRotationInterval)
+
+	c := &Container{
+		Config:        cfg,
+		Pool:          core.NewPool(cfg, nil, paymentEngine, shareStore),
+		FraudDetector: security.LaunchInspector(),
+		PaymentClient: rewardSender,
+		Consensus:     consensus,
+		Auth:          auth,
+		Metrics:       metrics.Handler(),
+	}
+	c.ShareStore = c.Pool.ShareStore
+
+	if err := c.validate(); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// validate checks invariants that are cheap to verify at startup but
+// expensive (a confused operator, a production incident) to discover
+// at first request.
+func (c *Container) validate() error {
+	if c.Config.Env != "production" {
+		return nil
+	}
+
+	if c.Config.Security.APIKey == "" || c.Config.Security.APIKey == "changeme" {
+		return fmt.Errorf("pool: APP_ENV=production requires Security.APIKey to be set to a real value")
+	}
+
+	if c.PaymentClient == nil {
+		return fmt.Errorf("pool: APP_ENV=production requires a payment client that supports SendReward")
+	}
+
+	return nil
+}
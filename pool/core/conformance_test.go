@@ -0,0 +1,110 @@
+package core
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// shareVector is the subset of pool/testdata/vectors/*.json's schema
+// this runner cares about (kind "share_validator"); the Inspector-facing
+// kinds are walked by security/conformance_test.go instead.
+type shareVector struct {
+	Name        string `json:"name"`
+	Kind        string `json:"kind"`
+	Description string `json:"description"`
+
+	Target      string           `json:"target"`
+	TTLMS       int64            `json:"ttl_ms"`
+	Submissions []shareSubmitted `json:"submissions"`
+	WantValid   bool             `json:"want_valid"`
+	WantStatus  string           `json:"want_status"`
+}
+
+type shareSubmitted struct {
+	ID       string `json:"id"`
+	JobID    string `json:"job_id"`
+	WorkerID string `json:"worker_id"`
+	Nonce    string `json:"nonce"`
+	AgeMS    int64  `json:"age_ms"`
+}
+
+func loadShareVectors(t *testing.T, dir string) []shareVector {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("failed to read vectors dir %s: %v", dir, err)
+	}
+
+	var vectors []shareVector
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".json") {
+			continue
+		}
+		path := filepath.Join(dir, e.Name())
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("failed to read vector %s: %v", path, err)
+		}
+		var v shareVector
+		if err := json.Unmarshal(data, &v); err != nil {
+			t.Fatalf("failed to parse vector %s: %v", path, err)
+		}
+		if v.Kind != "share_validator" {
+			continue
+		}
+		vectors = append(vectors, v)
+	}
+	return vectors
+}
+
+// TestShareValidatorConformance walks the shared vector corpus and
+// replays every share_validator vector's submission sequence through a
+// fresh ShareProcessor, comparing the final submission's verdict against
+// the vector's expectation. Set SKIP_CONFORMANCE=1 to opt out; set
+// POOL_VECTORS_DIR to load vectors from outside this repo.
+func TestShareValidatorConformance(t *testing.T) {
+	if os.Getenv("SKIP_CONFORMANCE") == "1" {
+		t.Skip("SKIP_CONFORMANCE=1")
+	}
+
+	dir := os.Getenv("POOL_VECTORS_DIR")
+	if dir == "" {
+		dir = filepath.Join("..", "testdata", "vectors")
+	}
+
+	for _, v := range loadShareVectors(t, dir) {
+		t.Run(v.Name, func(t *testing.T) {
+			runShareVector(t, v)
+		})
+	}
+}
+
+func runShareVector(t *testing.T, v shareVector) {
+	t.Helper()
+
+	proc := NewShareProcessor(nil, nil)
+	ttl := time.Duration(v.TTLMS) * time.Millisecond
+
+	var result ShareResult
+	for _, sc := range v.Submissions {
+		share := Share{
+			ID:        sc.ID,
+			JobID:     sc.JobID,
+			WorkerID:  sc.WorkerID,
+			Nonce:     sc.Nonce,
+			Timestamp: time.Now().Add(-time.Duration(sc.AgeMS) * time.Millisecond),
+		}
+		result = proc.Process(share, v.Target, ttl)
+	}
+
+	if result.Valid != v.WantValid {
+		t.Errorf("got valid=%v, want %v (description: %s)", result.Valid, v.WantValid, result.Description)
+	}
+	if result.Status.String() != v.WantStatus {
+		t.Errorf("got status=%s, want %s", result.Status, v.WantStatus)
+	}
+}
@@ -0,0 +1,151 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"pool/metrics"
+)
+
+// ConsensusValidator confirms a locally-valid share against a set of peer
+// validators before ShareProcessor accepts it. Pools that run more than
+// one independent validation node wire this in so a single compromised
+// or buggy node can't unilaterally accept a share.
+type ConsensusValidator interface {
+	Confirm(ctx context.Context, share Share) (bool, error)
+}
+
+// HTTPConsensusValidator fans a share out to Endpoints in parallel and
+// accepts it once at least MinConfirmation of the endpoints that
+// actually responded report it valid. It requires at least MinSubmit
+// endpoints to respond at all (regardless of verdict) before trusting
+// the round, tolerating up to len(Endpoints)-MinSubmit failures or
+// timeouts.
+type HTTPConsensusValidator struct {
+	Endpoints       []string
+	MinSubmit       int
+	MinConfirmation int
+	Client          *http.Client
+}
+
+// NewHTTPConsensusValidator validates that MinConfirmation is achievable
+// with the given endpoint set before returning a validator an operator
+// could actually run.
+func NewHTTPConsensusValidator(endpoints []string, minSubmit, minConfirmation int) (*HTTPConsensusValidator, error) {
+	if minConfirmation > len(endpoints) {
+		return nil, fmt.Errorf("core: MinConfirmation (%d) cannot exceed the number of validator endpoints (%d)", minConfirmation, len(endpoints))
+	}
+	return &HTTPConsensusValidator{
+		Endpoints:       endpoints,
+		MinSubmit:       minSubmit,
+		MinConfirmation: minConfirmation,
+		Client:          http.DefaultClient,
+	}, nil
+}
+
+type consensusRequest struct {
+	JobID    string  `json:"job_id"`
+	WorkerID string  `json:"worker_id"`
+	Nonce    string  `json:"nonce"`
+	Hash     string  `json:"hash"`
+	Diff     float64 `json:"difficulty"`
+}
+
+type consensusResponse struct {
+	Valid bool `json:"valid"`
+}
+
+type consensusOutcome struct {
+	valid bool
+	err   error
+}
+
+// Confirm issues one POST per endpoint, each bounded by ctx's deadline
+// (set upstream by the HTTP layer's withTimeout middleware), and reports
+// whether enough of them agree the share is valid. A nil Endpoints list
+// is treated as consensus mode being disabled, so callers can wire this
+// in unconditionally and let config decide whether it does anything.
+func (v *HTTPConsensusValidator) Confirm(ctx context.Context, share Share) (bool, error) {
+	if len(v.Endpoints) == 0 {
+		return true, nil
+	}
+
+	body, err := json.Marshal(consensusRequest{
+		JobID:    share.JobID,
+		WorkerID: share.WorkerID,
+		Nonce:    share.Nonce,
+		Hash:     share.Hash,
+		Diff:     share.Diff,
+	})
+	if err != nil {
+		return false, fmt.Errorf("core: failed to encode consensus request: %w", err)
+	}
+
+	results := make([]consensusOutcome, len(v.Endpoints))
+	done := make(chan int, len(v.Endpoints))
+	for i, endpoint := range v.Endpoints {
+		go func(i int, endpoint string) {
+			valid, err := v.confirmOne(ctx, endpoint, body)
+			results[i] = consensusOutcome{valid: valid, err: err}
+			done <- i
+		}(i, endpoint)
+	}
+	for range v.Endpoints {
+		<-done
+	}
+
+	var responded, agreed int
+	for _, r := range results {
+		switch {
+		case r.err != nil && errors.Is(r.err, context.DeadlineExceeded):
+			metrics.ConsensusOutcome.WithLabelValues("timeout").Inc()
+		case r.err != nil:
+			metrics.ConsensusOutcome.WithLabelValues("disagreed").Inc()
+		case r.valid:
+			responded++
+			agreed++
+			metrics.ConsensusOutcome.WithLabelValues("agreed").Inc()
+		default:
+			responded++
+			metrics.ConsensusOutcome.WithLabelValues("disagreed").Inc()
+		}
+	}
+
+	if responded < v.MinSubmit {
+		return false, fmt.Errorf("core: only %d/%d validator endpoints responded, need at least %d", responded, len(v.Endpoints), v.MinSubmit)
+	}
+	return agreed >= v.MinConfirmation, nil
+}
+
+func (v *HTTPConsensusValidator) confirmOne(ctx context.Context, endpoint string, body []byte) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	client := v.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("core: validator %s returned status %d", endpoint, resp.StatusCode)
+	}
+
+	var out consensusResponse
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return false, fmt.Errorf("core: validator %s returned invalid response: %w", endpoint, err)
+	}
+	return out.Valid, nil
+}
@@ -0,0 +1,229 @@
+package core
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"sync"
+	"time"
+
+	"pool/metrics"
+)
+
+// maxTargetHex is the easiest possible target (difficulty 1), used as
+// the basis for converting a difficulty value into a target string.
+const maxTargetHex = "00000000ffff0000000000000000000000000000000000000000000000000000"
+
+// vardiffWindowSize bounds how many recent submission timestamps each
+// worker's ring buffer keeps. The observed share rate is derived from
+// the span this window covers rather than a single most-recent interval,
+// so one unusually fast or slow share can't swing a retarget on its own.
+const vardiffWindowSize = 60
+
+// minRetargetRatio/maxRetargetRatio clamp the multiplier retarget
+// applies to a worker's current difficulty in a single step, so one
+// retargetInterval's worth of observed rate can't move a worker
+// straight from minDiff to maxDiff.
+const (
+	minRetargetRatio = 0.5
+	maxRetargetRatio = 2.0
+)
+
+// DifficultyController assigns each worker its own target, adjusting it
+// periodically toward a configured shares-per-minute rate from a
+// sliding window of recent submission timestamps (vardiff).
+type DifficultyController struct {
+	mu      sync.Mutex
+	workers map[WorkerIdentifier]*workerDiffState
+
+	targetSharesPerMinute float64
+	minDiff               float64
+	maxDiff               float64
+	retargetInterval      time.Duration
+
+	// OnRetarget is invoked whenever a worker's difficulty changes, so
+	// the Stratum layer can push mining.set_difficulty.
+	OnRetarget func(workerID WorkerIdentifier, newDiff float64)
+}
+
+type workerDiffState struct {
+	currentDiff   float64
+	window        []time.Time // ring buffer of recent submission timestamps, capped at vardiffWindowSize
+	lastRetarget  time.Time
+	retargetCount int
+}
+
+// NewDifficultyController builds a controller clamped to [minDiff,maxDiff]
+// that retargets at most once per retargetInterval per worker.
+func NewDifficultyController(minDiff, maxDiff, targetSharesPerMinute float64, retargetInterval time.Duration) *DifficultyController {
+	if retargetInterval <= 0 {
+		retargetInterval = 30 * time.Second
+	}
+	return &DifficultyController{
+		workers:               make(map[WorkerIdentifier]*workerDiffState),
+		targetSharesPerMinute: targetSharesPerMinute,
+		minDiff:               minDiff,
+		maxDiff:               maxDiff,
+		retargetInterval:      retargetInterval,
+	}
+}
+
+// RecordShare folds in a new submission timestamp for workerID and
+// retargets the worker's difficulty if enough time has passed.
+func (dc *DifficultyController) RecordShare(workerID WorkerIdentifier) {
+	now := time.Now()
+
+	dc.mu.Lock()
+	defer dc.mu.Unlock()
+
+	st, ok := dc.workers[workerID]
+	if !ok {
+		st = &workerDiffState{currentDiff: dc.clamp(1), window: []time.Time{now}, lastRetarget: now}
+		dc.workers[workerID] = st
+		return
+	}
+
+	st.window = append(st.window, now)
+	if len(st.window) > vardiffWindowSize {
+		st.window = st.window[len(st.window)-vardiffWindowSize:]
+	}
+
+	if now.Sub(st.lastRetarget) < dc.retargetInterval {
+		return
+	}
+	dc.retarget(workerID, st, now)
+}
+
+func (dc *DifficultyController) retarget(workerID WorkerIdentifier, st *workerDiffState, now time.Time) {
+	avgIntervalSecs, ok := windowAvgIntervalSecs(st.window)
+	if !ok || dc.targetSharesPerMinute <= 0 {
+		return
+	}
+
+	observedSharesPerMinute := 60.0 / avgIntervalSecs
+	ratio := observedSharesPerMinute / dc.targetSharesPerMinute
+	if ratio <= 0 {
+		return
+	}
+	if ratio < minRetargetRatio {
+		ratio = minRetargetRatio
+	} else if ratio > maxRetargetRatio {
+		ratio = maxRetargetRatio
+	}
+
+	newDiff := dc.clamp(st.currentDiff * ratio)
+	if newDiff == st.currentDiff {
+		return
+	}
+
+	st.currentDiff = newDiff
+	st.lastRetarget = now
+	st.retargetCount++
+
+	if metrics.WorkerHashrateEstimate != nil {
+		// Per the repo's pool_vardiff_* convention (RetargetCount,
+		// CurrentDiff, SharesPerMinute already cover per-worker
+		// difficulty/rate), this adds the one gauge those don't: an
+		// estimated hashrate derived from diff*2^32/avgShareInterval.
+		hashrate := newDiff * math.Pow(2, 32) / avgIntervalSecs
+		metrics.WorkerHashrateEstimate.WithLabelValues(string(workerID)).Set(hashrate)
+	}
+
+	if dc.OnRetarget != nil {
+		dc.OnRetarget(workerID, newDiff)
+	}
+}
+
+// windowAvgIntervalSecs returns the average gap between consecutive
+// timestamps in window, derived from the span the whole window covers
+// rather than any single interval. ok is false when window doesn't yet
+// hold enough samples to mean anything.
+func windowAvgIntervalSecs(window []time.Time) (avg float64, ok bool) {
+	if len(window) < 2 {
+		return 0, false
+	}
+	elapsed := window[len(window)-1].Sub(window[0]).Seconds()
+	if elapsed <= 0 {
+		return 0, false
+	}
+	return elapsed / float64(len(window)-1), true
+}
+
+// SetBounds updates the [minDiff,maxDiff] clamp range in place, so a
+// config reload of MIN_DIFFICULTY/MAX_DIFFICULTY applies to future
+// retargets without losing each worker's sliding-window state.
+func (dc *DifficultyController) SetBounds(minDiff, maxDiff float64) {
+	dc.mu.Lock()
+	defer dc.mu.Unlock()
+	dc.minDiff = minDiff
+	dc.maxDiff = maxDiff
+}
+
+func (dc *DifficultyController) clamp(diff float64) float64 {
+	if dc.minDiff > 0 && diff < dc.minDiff {
+		return dc.minDiff
+	}
+	if dc.maxDiff > 0 && diff > dc.maxDiff {
+		return dc.maxDiff
+	}
+	return diff
+}
+
+// CurrentDiff returns the worker's current difficulty, defaulting to 1
+// clamped to [minDiff,maxDiff] for workers that haven't submitted a
+// share yet.
+func (dc *DifficultyController) CurrentDiff(workerID WorkerIdentifier) float64 {
+	dc.mu.Lock()
+	defer dc.mu.Unlock()
+	if st, ok := dc.workers[workerID]; ok {
+		return st.currentDiff
+	}
+	return dc.clamp(1)
+}
+
+// SharesPerMinute returns the worker's recent observed share rate.
+func (dc *DifficultyController) SharesPerMinute(workerID WorkerIdentifier) float64 {
+	dc.mu.Lock()
+	defer dc.mu.Unlock()
+	st, ok := dc.workers[workerID]
+	if !ok {
+		return 0
+	}
+	avgIntervalSecs, ok := windowAvgIntervalSecs(st.window)
+	if !ok {
+		return 0
+	}
+	return 60.0 / avgIntervalSecs
+}
+
+// RetargetCount returns how many times workerID's difficulty has changed.
+func (dc *DifficultyController) RetargetCount(workerID WorkerIdentifier) int {
+	dc.mu.Lock()
+	defer dc.mu.Unlock()
+	if st, ok := dc.workers[workerID]; ok {
+		return st.retargetCount
+	}
+	return 0
+}
+
+// TargetFor converts the worker's current difficulty into a target hex
+// string suitable for ShareValidator.IsValidHash.
+func (dc *DifficultyController) TargetFor(workerID WorkerIdentifier) string {
+	return diffToTargetHex(dc.CurrentDiff(workerID))
+}
+
+func diffToTargetHex(diff float64) string {
+	if diff <= 0 {
+		diff = 1
+	}
+	maxTarget, ok := new(big.Int).SetString(maxTargetHex, 16)
+	if !ok {
+		return maxTargetHex
+	}
+	scaled := new(big.Float).Quo(new(big.Float).SetInt(maxTarget), big.NewFloat(diff))
+	target, _ := scaled.Int(nil)
+	// Zero-pad to the fixed 64-char width calculateHash/hashJob always
+	// produce, so IsValidHash's plain string comparison is equivalent to
+	// a numeric one instead of comparing differently-lengthed strings.
+	return fmt.Sprintf("%064x", target)
+}
@@ -1,13 +1,19 @@
 package core
 
 import (
+	"bytes"
+	"context"
 	"crypto/sha256"
+	"encoding/gob"
 	"encoding/hex"
+	"fmt"
 	"math/rand"
+	"os"
 	"sync"
 	"time"
 
 	"pool/logs"
+	"pool/metrics"
 	"pool/utils"
 )
 
@@ -26,6 +32,11 @@ type JobManager struct {
 	jobs    map[string]*Job
 	lock    sync.RWMutex
 	timeout time.Duration
+
+	// OnJobCreated, if set, is invoked with every job CreateJob produces.
+	// The Stratum front-end hooks in here to broadcast mining.notify
+	// without JobManager depending on the stratum package.
+	OnJobCreated func(job *Job)
 }
 
 func NewJobManager(timeout time.Duration) *JobManager {
@@ -35,6 +46,170 @@ func NewJobManager(timeout time.Duration) *JobManager {
 	}
 }
 
+// jobSnapshotVersion is bumped whenever jobDTO's fields change in a way
+// that isn't backward compatible, so LoadSnapshot can tell a stale
+// snapshot apart from a corrupt one.
+const jobSnapshotVersion = 1
+
+// jobDTO is Job's on-disk shape. Job itself isn't gob-encoded directly
+// because of its embedded sync.RWMutex, which has no business surviving
+// a restart.
+type jobDTO struct {
+	ID          string
+	Data        string
+	Target      string
+	CreatedAt   time.Time
+	ExpiresAt   time.Time
+	BlockHeight int
+	Active      bool
+}
+
+// MarshalBinary encodes j as a jobDTO via gob, dropping its mutex.
+func (j *Job) MarshalBinary() ([]byte, error) {
+	j.Mutex.RLock()
+	defer j.Mutex.RUnlock()
+
+	var buf bytes.Buffer
+	dto := jobDTO{
+		ID:          j.ID,
+		Data:        j.Data,
+		Target:      j.Target,
+		CreatedAt:   j.CreatedAt,
+		ExpiresAt:   j.ExpiresAt,
+		BlockHeight: j.BlockHeight,
+		Active:      j.Active,
+	}
+	if err := gob.NewEncoder(&buf).Encode(dto); err != nil {
+		return nil, fmt.Errorf("core: encode job %s: %w", j.ID, err)
+	}
+	return buf.Bytes(), nil
+}
+
+// UnmarshalBinary decodes a jobDTO produced by MarshalBinary into j.
+func (j *Job) UnmarshalBinary(data []byte) error {
+	var dto jobDTO
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&dto); err != nil {
+		return fmt.Errorf("core: decode job: %w", err)
+	}
+	j.ID = dto.ID
+	j.Data = dto.Data
+	j.Target = dto.Target
+	j.CreatedAt = dto.CreatedAt
+	j.ExpiresAt = dto.ExpiresAt
+	j.BlockHeight = dto.BlockHeight
+	j.Active = dto.Active
+	return nil
+}
+
+// jobManagerSnapshot is the on-disk shape written by SnapshotEvery and
+// read by LoadSnapshot. Version lets future field additions to jobDTO
+// stay backward compatible with snapshots written by older builds.
+type jobManagerSnapshot struct {
+	Version int
+	Jobs    []jobDTO
+}
+
+// SnapshotEvery starts a background goroutine that writes jm's jobs to
+// path every interval d, so a restart doesn't lose every in-flight job.
+// Each write goes to "${path}.tmp" first and is then renamed over path,
+// so a crash mid-write never leaves a half-written snapshot behind.
+func (jm *JobManager) SnapshotEvery(d time.Duration, path string) {
+	go func() {
+		ticker := time.NewTicker(d)
+		defer ticker.Stop()
+		for range ticker.C {
+			if err := jm.writeSnapshot(path); err != nil {
+				logs.Warnf("JobManager snapshot write failed: %v", err)
+			}
+		}
+	}()
+}
+
+func (jm *JobManager) writeSnapshot(path string) error {
+	jm.lock.RLock()
+	dtos := make([]jobDTO, 0, len(jm.jobs))
+	for _, job := range jm.jobs {
+		job.Mutex.RLock()
+		dtos = append(dtos, jobDTO{
+			ID:          job.ID,
+			Data:        job.Data,
+			Target:      job.Target,
+			CreatedAt:   job.CreatedAt,
+			ExpiresAt:   job.ExpiresAt,
+			BlockHeight: job.BlockHeight,
+			Active:      job.Active,
+		})
+		job.Mutex.RUnlock()
+	}
+	jm.lock.RUnlock()
+
+	var buf bytes.Buffer
+	snap := jobManagerSnapshot{Version: jobSnapshotVersion, Jobs: dtos}
+	if err := gob.NewEncoder(&buf).Encode(snap); err != nil {
+		return fmt.Errorf("core: encode job snapshot: %w", err)
+	}
+
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, buf.Bytes(), 0o600); err != nil {
+		return fmt.Errorf("core: write job snapshot tmp: %w", err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		return fmt.Errorf("core: rename job snapshot into place: %w", err)
+	}
+	return nil
+}
+
+// LoadSnapshot restores jobs previously written by SnapshotEvery from
+// path. Jobs whose ExpiresAt has already passed are dropped rather than
+// restored, so a pool that was down for a while doesn't come back
+// accepting shares against stale work. A missing file is not an error —
+// there's simply nothing to restore yet. A snapshot that fails to
+// decode is treated as corrupt: it's discarded (not restored from) and
+// counted via metrics.SnapshotCorrupted instead of panicking, since a
+// corrupt snapshot just means starting with an empty job set.
+func (jm *JobManager) LoadSnapshot(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("core: read job snapshot: %w", err)
+	}
+
+	var snap jobManagerSnapshot
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&snap); err != nil {
+		metrics.SnapshotCorrupted.WithLabelValues("jobmanager").Inc()
+		logs.Warnf("JobManager snapshot at %s is corrupt, starting empty: %v", path, err)
+		return nil
+	}
+	if snap.Version != jobSnapshotVersion {
+		logs.Warnf("JobManager snapshot at %s has version %d, expected %d; ignoring", path, snap.Version, jobSnapshotVersion)
+		return nil
+	}
+
+	now := time.Now()
+	jm.lock.Lock()
+	defer jm.lock.Unlock()
+	restored := 0
+	for _, dto := range snap.Jobs {
+		if now.After(dto.ExpiresAt) {
+			continue
+		}
+		jm.jobs[dto.ID] = &Job{
+			ID:          dto.ID,
+			Data:        dto.Data,
+			Target:      dto.Target,
+			CreatedAt:   dto.CreatedAt,
+			ExpiresAt:   dto.ExpiresAt,
+			BlockHeight: dto.BlockHeight,
+			Active:      dto.Active,
+		}
+		restored++
+	}
+	logs.Infof("JobManager restored %d/%d jobs from snapshot %s", restored, len(snap.Jobs), path)
+	return nil
+}
+
 func (jm *JobManager) CreateJob(blockHeight int) *Job {
 	randomSeed := utils.GenerateRandomHex(32)
 	data := utils.GenerateRandomHex(64) + randomSeed
@@ -56,10 +231,19 @@ func (jm *JobManager) CreateJob(blockHeight int) *Job {
 	jm.jobs[job.ID] = job
 
 	logs.Debugf("New job created: %s | BlockHeight: %d", job.ID, blockHeight)
+	if jm.OnJobCreated != nil {
+		jm.OnJobCreated(job)
+	}
 	return job
 }
 
-func (jm *JobManager) ValidateShare(jobID, nonce, result string) bool {
+// ValidateShare validates a submitted share against jobID's recorded
+// work. It runs the check (including acquiring job.Mutex, which could in
+// principle sit behind a slow holder) on a separate goroutine and races
+// it against ctx, so a caller enforcing a per-request deadline — e.g.
+// the HTTP server's WithTimeout middleware — gets back false the moment
+// ctx expires instead of blocking past it.
+func (jm *JobManager) ValidateShare(ctx context.Context, jobID, nonce, result string) bool {
 	jm.lock.RLock()
 	job, exists := jm.jobs[jobID]
 	jm.lock.RUnlock()
@@ -68,6 +252,21 @@ func (jm *JobManager) ValidateShare(jobID, nonce, result string) bool {
 		return false
 	}
 
+	done := make(chan bool, 1)
+	go func() {
+		done <- validateJobShare(job, jobID, nonce, result)
+	}()
+
+	select {
+	case ok := <-done:
+		return ok
+	case <-ctx.Done():
+		logs.Warnf("Share validation for job %s abandoned: %v", jobID, ctx.Err())
+		return false
+	}
+}
+
+func validateJobShare(job *Job, jobID, nonce, result string) bool {
 	job.Mutex.Lock()
 	defer job.Mutex.Unlock()
 	if time.Now().After(job.ExpiresAt) {
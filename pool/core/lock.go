@@ -0,0 +1,9 @@
+package core
+
+// LockToken identifies a held balance lease, as returned by a
+// BalanceStore's AcquireLock (pool/http.BalanceStore). It lives here
+// rather than in pool/http so that pool/database's Postgres-backed
+// implementation can return it too without pool/database having to
+// import pool/http (which already imports pool, which would need to
+// import pool/database to wire one in — a cycle).
+type LockToken string
@@ -35,6 +35,12 @@ type PoolCore struct {
 	muShares sync.Mutex
 	muJobs   sync.RWMutex
 	muWrks   sync.RWMutex
+
+	// OnJobDispatched, if set, is invoked with every job DispatchJob
+	// accepts. The Stratum V2 front-end hooks in here to translate an
+	// operator-pushed job into per-connection NewMiningJob/SetNewPrevHash
+	// notifications without PoolCore depending on the stratum package.
+	OnJobDispatched func(job *Job)
 }
 
 func InitPool(ttl, rate time.Duration) *PoolCore {
@@ -84,6 +90,10 @@ func (pc *PoolCore) DispatchJob(j *Job) {
 	pc.activeJobs[JobIdentifier(j.ID)] = j
 	pc.muJobs.Unlock()
 	pc.removeExpiredJobs()
+
+	if pc.OnJobDispatched != nil {
+		pc.OnJobDispatched(j)
+	}
 }
 
 func (pc *PoolCore) SubmitShare(wid WorkerIdentifier, s *Share) error {
@@ -197,7 +207,7 @@ func (p *Pool) ProcessShare(ctx context.Context, s Share) (ShareResult, error) {
 		}, nil
 	}
 
-	if verdict := security.EvaluateShare(s.WorkerID, s.IP, s.Nonce, s.Hash, s.Timestamp); verdict.Flagged {
+	if verdict := security.EvaluateShare(ctx, s.WorkerID, s.IP, s.Nonce, s.Hash, s.Timestamp); verdict.Flagged {
 		if metrics.SharesInvalid != nil {
 			metrics.SharesInvalid.Inc()
 		}
@@ -210,17 +220,8 @@ func (p *Pool) ProcessShare(ctx context.Context, s Share) (ShareResult, error) {
 	}
 
 	if p.ShareStore != nil {
-		if exists, err := p.ShareStore.Exists(s.ID); err == nil && exists {
-			return ShareResult{
-				Status:      ShareAccepted, // mantém aceito/ignorado para UX
-				Description: "duplicate share ignored",
-				Valid:       true,
-			}, nil
-		}
-	}
-
-	if p.ShareStore != nil {
-		if err := p.ShareStore.Save(s); err != nil {
+		created, err := p.ShareStore.SaveIfAbsent(s)
+		if err != nil {
 			if metrics.SharesInvalid != nil {
 				metrics.SharesInvalid.Inc()
 			}
@@ -231,6 +232,13 @@ func (p *Pool) ProcessShare(ctx context.Context, s Share) (ShareResult, error) {
 				Valid:       false,
 			}, err
 		}
+		if !created {
+			return ShareResult{
+				Status:      ShareAccepted, // mantém aceito/ignorado para UX
+				Description: "duplicate share ignored",
+				Valid:       true,
+			}, nil
+		}
 	}
 
 	if metrics.SharesValid != nil {
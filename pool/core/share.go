@@ -1,11 +1,16 @@
 package core
 
 import (
+	"context"
 	"crypto/sha256"
 	"encoding/hex"
 	"fmt"
 	"sync"
 	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+
+	"pool/telemetry"
 )
 
 type ShareStatus int
@@ -17,6 +22,21 @@ const (
 	ShareInvalid
 )
 
+func (s ShareStatus) String() string {
+	switch s {
+	case ShareAccepted:
+		return "accepted"
+	case ShareDuplicate:
+		return "duplicate"
+	case ShareStale:
+		return "stale"
+	case ShareInvalid:
+		return "invalid"
+	default:
+		return "unknown"
+	}
+}
+
 type Share struct {
 	ID        string    `json:"id,omitempty"`
 	JobID     string    `json:"job_id"`
@@ -45,6 +65,15 @@ type ShareValidator interface {
 type ShareStore interface {
 	Exists(shareID string) (bool, error)
 	Save(share Share) error
+
+	// SaveIfAbsent persists share only if no share with the same ID has
+	// been stored yet, atomically with the existence check. Callers that
+	// only care about "did my submission win the race" should prefer
+	// this over a separate Exists+Save pair, which leaves a window for
+	// two concurrent submitters to both see !exists and both Save.
+	// created is false (with a nil error) when another submitter's share
+	// already occupies that ID.
+	SaveIfAbsent(share Share) (created bool, err error)
 }
 
 type internalStore struct {
@@ -75,6 +104,16 @@ func (s *internalStore) Save(share Share) error {
 	return nil
 }
 
+func (s *internalStore) SaveIfAbsent(share Share) (bool, error) {
+	s.Lock()
+	defer s.Unlock()
+	if _, exists := s.entries[share.ID]; exists {
+		return false, nil
+	}
+	s.entries[share.ID] = share
+	return true, nil
+}
+
 func (s *internalStore) cleanup() {
 	s.Lock()
 	defer s.Unlock()
@@ -95,6 +134,9 @@ func NewDefaultShareValidator(store ShareStore) *DefaultShareValidator {
 }
 
 func (v *DefaultShareValidator) ValidateShare(share Share, target string, ttl time.Duration) ShareResult {
+	if v.shareStore == nil {
+		return ShareResult{Status: ShareInvalid, Description: "share store not configured", Valid: false}
+	}
 	switch {
 	case v.IsDuplicate(share):
 		return ShareResult{Status: ShareDuplicate, Description: "duplicate share", Valid: false}
@@ -107,7 +149,14 @@ func (v *DefaultShareValidator) ValidateShare(share Share, target string, ttl ti
 	}
 }
 
+// IsDuplicate reports a share as not-a-duplicate rather than panicking
+// when called directly against a nil store; ValidateShare is the usual
+// entry point and already rejects a nil store outright, so this is a
+// defense-in-depth fallback for any other caller, not the primary fix.
 func (v *DefaultShareValidator) IsDuplicate(share Share) bool {
+	if v.shareStore == nil {
+		return false
+	}
 	found, _ := v.shareStore.Exists(share.ID)
 	return found
 }
@@ -124,6 +173,23 @@ func (v *DefaultShareValidator) IsValidHash(share Share, target string) bool {
 type ShareProcessor struct {
 	validator ShareValidator
 	store     ShareStore
+
+	// OnAccepted, if set, is invoked after a share has been validated
+	// and persisted. Payout schemes hook in here to fold the share into
+	// their balance calculations without ShareProcessor depending on
+	// the payout package.
+	OnAccepted func(share Share)
+
+	// Difficulty, if set, overrides the caller-supplied target with the
+	// worker's current vardiff target and feeds accepted shares back
+	// into the controller's submission-rate tracking.
+	Difficulty *DifficultyController
+
+	// Consensus, if set, is asked to confirm a locally-valid share
+	// against peer validators before it is persisted. Both Process and
+	// ProcessContext consult it; Process passes context.Background(), so
+	// its peer requests carry no caller deadline.
+	Consensus ConsensusValidator
 }
 
 func NewShareProcessor(v ShareValidator, store ShareStore) *ShareProcessor {
@@ -136,12 +202,59 @@ func NewShareProcessor(v ShareValidator, store ShareStore) *ShareProcessor {
 	return &ShareProcessor{validator: v, store: store}
 }
 
+// ProcessContext is Process with a span attached, so the HTTP and
+// Stratum front-ends can carry worker_id/job_id/ShareStatus through to a
+// collector. Process itself stays context-free so existing call sites
+// don't need to thread a context just to validate a share.
+func (sp *ShareProcessor) ProcessContext(ctx context.Context, share Share, target string, ttl time.Duration) ShareResult {
+	_, span := telemetry.Tracer().Start(ctx, "core.ShareProcessor.Process")
+	defer span.End()
+
+	result := sp.process(ctx, share, target, ttl)
+
+	span.SetAttributes(
+		attribute.String("worker_id", share.WorkerID),
+		attribute.String("job_id", share.JobID),
+		attribute.String("share_status", result.Status.String()),
+	)
+	return result
+}
+
 func (sp *ShareProcessor) Process(share Share, target string, ttl time.Duration) ShareResult {
+	return sp.process(context.Background(), share, target, ttl)
+}
+
+func (sp *ShareProcessor) process(ctx context.Context, share Share, target string, ttl time.Duration) ShareResult {
+	if sp.Difficulty != nil {
+		target = sp.Difficulty.TargetFor(WorkerIdentifier(share.WorkerID))
+	}
+
 	result := sp.validator.ValidateShare(share, target, ttl)
 	if !result.Valid {
 		result.Error = fmt.Errorf("rejected: %s", result.Description)
 		return result
 	}
+
+	if sp.Consensus != nil {
+		agreed, err := sp.Consensus.Confirm(ctx, share)
+		if err != nil {
+			return ShareResult{
+				Status:      ShareInvalid,
+				Description: "consensus error: " + err.Error(),
+				Valid:       false,
+				Error:       err,
+			}
+		}
+		if !agreed {
+			return ShareResult{
+				Status:      ShareInvalid,
+				Description: "consensus disagreement",
+				Valid:       false,
+				Error:       fmt.Errorf("rejected: consensus disagreement"),
+			}
+		}
+	}
+
 	if err := sp.store.Save(share); err != nil {
 		return ShareResult{
 			Status:      ShareInvalid,
@@ -150,6 +263,12 @@ func (sp *ShareProcessor) Process(share Share, target string, ttl time.Duration)
 			Error:       err,
 		}
 	}
+	if sp.Difficulty != nil {
+		sp.Difficulty.RecordShare(WorkerIdentifier(share.WorkerID))
+	}
+	if sp.OnAccepted != nil {
+		sp.OnAccepted(share)
+	}
 	return result
 }
 
@@ -0,0 +1,59 @@
+package core
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+// racyExistsThenSave reproduces the Exists-then-Save sequence ProcessShare
+// used before SaveIfAbsent, so BenchmarkShareStore_Concurrent can show the
+// throughput difference between the two under contention.
+func racyExistsThenSave(store *internalStore, share Share) (created bool, err error) {
+	exists, err := store.Exists(share.ID)
+	if err != nil {
+		return false, err
+	}
+	if exists {
+		return false, nil
+	}
+	if err := store.Save(share); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func benchmarkConcurrentSaves(b *testing.B, workers int, save func(store *internalStore, share Share) (bool, error)) {
+	store := newInternalStore(time.Minute)
+	b.ResetTimer()
+
+	for n := 0; n < b.N; n++ {
+		var wg sync.WaitGroup
+		wg.Add(workers)
+		for w := 0; w < workers; w++ {
+			go func(w int) {
+				defer wg.Done()
+				share := Share{ID: fmt.Sprintf("share-%d-%d", n, w%(workers/10+1)), Timestamp: time.Now()}
+				_, _ = save(store, share)
+			}(w)
+		}
+		wg.Wait()
+	}
+}
+
+// BenchmarkShareStore_Concurrent compares the old Exists-then-Save race
+// against the atomic SaveIfAbsent under 1k concurrent submitters, a
+// fraction of which deliberately collide on the same share ID.
+func BenchmarkShareStore_Concurrent(b *testing.B) {
+	const workers = 1000
+
+	b.Run("ExistsThenSave", func(b *testing.B) {
+		benchmarkConcurrentSaves(b, workers, racyExistsThenSave)
+	})
+	b.Run("SaveIfAbsent", func(b *testing.B) {
+		benchmarkConcurrentSaves(b, workers, func(store *internalStore, share Share) (bool, error) {
+			return store.SaveIfAbsent(share)
+		})
+	})
+}
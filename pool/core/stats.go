@@ -14,22 +14,25 @@ type PoolStats struct {
 }
 
 var (
-	startTime   time.Time
-	statsLock   sync.RWMutex
-	defaultPool *PoolCore
+	startTime time.Time
+	statsOnce sync.Once
+	statsLock sync.RWMutex
 )
 
-func init() {
-	startTime = time.Now()
-	rand.Seed(time.Now().UnixNano())
-	defaultPool = InitPool(60*time.Second, 5*time.Second)
-}
+// GetCurrentPoolStats derives a PoolStats snapshot from pc, the engine
+// held by the running pool.Container. pc may be nil (e.g. a container
+// that hasn't finished bootstrapping yet), in which case worker count
+// and hashrate report zero.
+func GetCurrentPoolStats(pc *PoolCore) PoolStats {
+	statsOnce.Do(func() {
+		startTime = time.Now()
+		rand.Seed(time.Now().UnixNano())
+	})
 
-func GetCurrentPoolStats() PoolStats {
 	statsLock.RLock()
 	defer statsLock.RUnlock()
 
-	workers := fetchWorkerCount()
+	workers := fetchWorkerCount(pc)
 	hashrate := estimateHashrate(workers)
 	uptime := formatDuration(time.Since(startTime))
 
@@ -40,15 +43,15 @@ func GetCurrentPoolStats() PoolStats {
 	}
 }
 
-func fetchWorkerCount() int {
-	if defaultPool == nil {
+func fetchWorkerCount(pc *PoolCore) int {
+	if pc == nil {
 		return 0
 	}
 
-	defaultPool.muWrks.RLock()
-	defer defaultPool.muWrks.RUnlock()
+	pc.muWrks.RLock()
+	defer pc.muWrks.RUnlock()
 
-	return len(defaultPool.workers)
+	return len(pc.workers)
 }
 
 func estimateHashrate(workers int) float64 {
@@ -0,0 +1,142 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"pool/core"
+	"pool/utils"
+)
+
+// defaultLockRefreshTTL is how long RefreshLock extends a lease by.
+// BalanceStore.RefreshLock takes no ttl argument (the lease's original
+// caller-chosen TTL isn't threaded through to refresh time), so every
+// refresh re-arms the same fixed window instead.
+const defaultLockRefreshTTL = 10 * time.Second
+
+// ErrLockHeld is returned by AcquireLock when another holder's lease on
+// the same miner ID is still live.
+var ErrLockHeld = errors.New("database: balance lock already held by another holder")
+
+// ErrLockMismatch is returned by RefreshLock/ReleaseLock when token no
+// longer matches the lease on record (it expired and was reclaimed by
+// another holder, or was already released).
+var ErrLockMismatch = errors.New("database: lock token no longer matches the lease on record")
+
+// PostgresBalanceStore tracks each miner's pending balance and the
+// exclusive lease used to serialize balance reads/writes across
+// concurrent share credits and payout debits. Requires:
+//
+//	CREATE TABLE balances (
+//		miner_id VARCHAR(255) PRIMARY KEY,
+//		amount DOUBLE PRECISION NOT NULL DEFAULT 0
+//	);
+//	CREATE TABLE balance_locks (
+//		miner_id VARCHAR(255) PRIMARY KEY,
+//		token VARCHAR(255) NOT NULL,
+//		holder_id VARCHAR(255) NOT NULL,
+//		expires_at TIMESTAMP NOT NULL
+//	);
+type PostgresBalanceStore struct {
+	db *Postgres
+}
+
+func NewPostgresBalanceStore(pg *Postgres) *PostgresBalanceStore {
+	return &PostgresBalanceStore{db: pg}
+}
+
+func (b *PostgresBalanceStore) AddBalance(ctx context.Context, minerID string, delta float64) error {
+	_, err := b.db.DB.ExecContext(ctx, `
+		INSERT INTO balances (miner_id, amount)
+		VALUES ($1, $2)
+		ON CONFLICT (miner_id) DO UPDATE SET amount = balances.amount + EXCLUDED.amount
+	`, minerID, delta)
+	if err != nil {
+		return fmt.Errorf("add balance: %w", err)
+	}
+	return nil
+}
+
+func (b *PostgresBalanceStore) GetBalance(ctx context.Context, minerID string) (float64, error) {
+	var amount float64
+	err := b.db.DB.QueryRowContext(ctx, `SELECT amount FROM balances WHERE miner_id = $1`, minerID).Scan(&amount)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("get balance: %w", err)
+	}
+	return amount, nil
+}
+
+func (b *PostgresBalanceStore) ResetBalance(ctx context.Context, minerID string) error {
+	_, err := b.db.DB.ExecContext(ctx, `
+		INSERT INTO balances (miner_id, amount)
+		VALUES ($1, 0)
+		ON CONFLICT (miner_id) DO UPDATE SET amount = 0
+	`, minerID)
+	if err != nil {
+		return fmt.Errorf("reset balance: %w", err)
+	}
+	return nil
+}
+
+// AcquireLock takes the lease on minerID if it's unheld or the previous
+// holder's lease has already expired, atomically via INSERT ... ON
+// CONFLICT DO UPDATE ... WHERE guarded on expiry: the UPDATE only fires
+// (and RETURNING only produces a row) when the existing row's expires_at
+// is in the past, so two concurrent callers can't both win the same
+// live lease.
+func (b *PostgresBalanceStore) AcquireLock(ctx context.Context, minerID, holderID string, ttl time.Duration) (core.LockToken, error) {
+	token := utils.GenerateUUID()
+
+	var returned string
+	err := b.db.DB.QueryRowContext(ctx, `
+		INSERT INTO balance_locks (miner_id, token, holder_id, expires_at)
+		VALUES ($1, $2, $3, now() + $4 * interval '1 second')
+		ON CONFLICT (miner_id) DO UPDATE
+			SET token = EXCLUDED.token, holder_id = EXCLUDED.holder_id, expires_at = EXCLUDED.expires_at
+			WHERE balance_locks.expires_at < now()
+		RETURNING token
+	`, minerID, token, holderID, ttl.Seconds()).Scan(&returned)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", ErrLockHeld
+	}
+	if err != nil {
+		return "", fmt.Errorf("acquire lock: %w", err)
+	}
+	return core.LockToken(returned), nil
+}
+
+// RefreshLock extends token's expiry by defaultLockRefreshTTL, but only
+// while it still matches the lease currently on record.
+func (b *PostgresBalanceStore) RefreshLock(ctx context.Context, token core.LockToken) error {
+	res, err := b.db.DB.ExecContext(ctx, `
+		UPDATE balance_locks
+		SET expires_at = now() + $2 * interval '1 second'
+		WHERE token = $1 AND expires_at > now()
+	`, string(token), defaultLockRefreshTTL.Seconds())
+	if err != nil {
+		return fmt.Errorf("refresh lock: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrLockMismatch
+	}
+	return nil
+}
+
+// ReleaseLock gives up token's lease early. Releasing a token that no
+// longer matches the lease on record (already expired and reclaimed, or
+// already released) is a no-op rather than an error — the caller's
+// intent (not holding the lease anymore) is already satisfied.
+func (b *PostgresBalanceStore) ReleaseLock(ctx context.Context, token core.LockToken) error {
+	_, err := b.db.DB.ExecContext(ctx, `DELETE FROM balance_locks WHERE token = $1`, string(token))
+	if err != nil {
+		return fmt.Errorf("release lock: %w", err)
+	}
+	return nil
+}
@@ -5,15 +5,27 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"math/big"
 	"os"
 	"time"
 
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+
 	"pool/core"
 	"pool/logs"
+	"pool/telemetry"
 
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
 )
 
+// saveIfAbsentBackoff is the retry schedule SaveIfAbsent walks through
+// when Postgres reports a serialization failure (two submitters racing
+// the same share ID under the same conflict). After the last backoff is
+// exhausted, the row still not being ours is treated as a duplicate
+// rather than a hard error.
+var saveIfAbsentBackoff = []time.Duration{10 * time.Millisecond, 20 * time.Millisecond, 40 * time.Millisecond}
+
 type ctxKey string
 
 const RequestIDKey ctxKey = "request_id"
@@ -128,7 +140,68 @@ func (p *Postgres) Save(s core.Share) error {
 	return err
 }
 
+// SaveIfAbsent persists s only if no share with the same ID exists yet,
+// atomically via INSERT ... ON CONFLICT DO NOTHING RETURNING xmax: a row
+// coming back means this call's own insert won, a sql.ErrNoRows means
+// the ID was already taken. A serialization failure (another submitter's
+// transaction committed the same ID concurrently) is retried per
+// saveIfAbsentBackoff; if the row still isn't ours once that's
+// exhausted, the share is treated as a duplicate rather than an error.
+func (p *Postgres) SaveIfAbsent(s core.Share) (bool, error) {
+	const query = `
+		INSERT INTO shares (id, job_id, worker_id, nonce, hash, difficulty, timestamp, ip)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (id) DO NOTHING
+		RETURNING xmax
+	`
+
+	attempt := func() (bool, error) {
+		var xmax string
+		err := p.DB.QueryRow(query, s.ID, s.JobID, s.WorkerID, s.Nonce, s.Hash, s.Diff, s.Timestamp, s.IP).Scan(&xmax)
+		switch {
+		case err == nil:
+			return true, nil
+		case errors.Is(err, sql.ErrNoRows):
+			return false, nil
+		default:
+			return false, err
+		}
+	}
+
+	created, err := attempt()
+	for _, backoff := range saveIfAbsentBackoff {
+		if err == nil || !isSerializationFailure(err) {
+			break
+		}
+		time.Sleep(backoff)
+		created, err = attempt()
+	}
+	if err != nil && isSerializationFailure(err) {
+		// Backoff exhausted and the row still isn't ours: duplicate.
+		return false, nil
+	}
+
+	if err != nil {
+		logs.WithFields(map[string]interface{}{
+			"component": "postgres",
+			"share_id":  s.ID,
+			"error":     err.Error(),
+		}).Error("Failed to persist share")
+		return false, err
+	}
+	return created, nil
+}
+
+func isSerializationFailure(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "40001"
+}
+
 func (p *Postgres) Exec(ctx context.Context, query string, args ...any) error {
+	ctx, span := telemetry.Tracer().Start(ctx, "postgres.exec")
+	span.SetAttributes(attribute.String("db.statement", query))
+	defer span.End()
+
 	qCtx, cancel := context.WithTimeout(ctx, 4*time.Second)
 	defer cancel()
 
@@ -136,7 +209,8 @@ func (p *Postgres) Exec(ctx context.Context, query string, args ...any) error {
 
 	stmt, err := p.DB.PrepareContext(qCtx, query)
 	if err != nil {
-		logs.WithFields(map[string]interface{}{
+		span.SetStatus(codes.Error, err.Error())
+		logs.FromContext(ctx).WithFields(map[string]interface{}{
 			"request_id": reqID,
 			"query":      query,
 			"error":      err.Error(),
@@ -146,7 +220,8 @@ func (p *Postgres) Exec(ctx context.Context, query string, args ...any) error {
 	defer stmt.Close()
 
 	if _, err := stmt.ExecContext(qCtx, args...); err != nil {
-		logs.WithFields(map[string]interface{}{
+		span.SetStatus(codes.Error, err.Error())
+		logs.FromContext(ctx).WithFields(map[string]interface{}{
 			"request_id": reqID,
 			"query":      query,
 			"error":      err.Error(),
@@ -158,5 +233,68 @@ func (p *Postgres) Exec(ctx context.Context, query string, args ...any) error {
 }
 
 func (p *Postgres) QueryRow(ctx context.Context, query string, args ...any) *sql.Row {
+	ctx, span := telemetry.Tracer().Start(ctx, "postgres.query_row")
+	span.SetAttributes(attribute.String("db.statement", query))
+	defer span.End()
+
 	return p.DB.QueryRowContext(ctx, query, args...)
 }
+
+// RecordPayout appends an entry to the payouts ledger. Callers are
+// expected to have already confirmed the transaction succeeded; this
+// only persists the record for auditing and PendingBalances accounting.
+//
+// Requires a `payouts` table:
+//
+//	CREATE TABLE payouts (
+//		id SERIAL PRIMARY KEY,
+//		worker_id VARCHAR(255) NOT NULL,
+//		amount NUMERIC(78,0) NOT NULL,
+//		tx_hash VARCHAR(255) NOT NULL,
+//		created_at TIMESTAMP NOT NULL DEFAULT NOW()
+//	);
+func (p *Postgres) RecordPayout(workerID string, amount *big.Int, txHash string) error {
+	_, err := p.DB.Exec(`
+		INSERT INTO payouts (worker_id, amount, tx_hash)
+		VALUES ($1, $2, $3)
+	`, workerID, amount.String(), txHash)
+
+	if err != nil {
+		logs.WithFields(map[string]interface{}{
+			"component": "postgres",
+			"worker_id": workerID,
+			"error":     err.Error(),
+		}).Error("Failed to record payout")
+	}
+	return err
+}
+
+// PendingBalances sums, per worker, the payouts rows whose tx_hash is
+// NULL. Accepted shares are not consulted, and RecordPayout always
+// writes a tx_hash, so rows it records are never counted here.
+func (p *Postgres) PendingBalances() (map[string]*big.Int, error) {
+	rows, err := p.DB.Query(`
+		SELECT worker_id, COALESCE(SUM(amount), 0) AS pending
+		FROM payouts
+		WHERE tx_hash IS NULL
+		GROUP BY worker_id
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("pending balances query: %w", err)
+	}
+	defer rows.Close()
+
+	out := make(map[string]*big.Int)
+	for rows.Next() {
+		var workerID, amountStr string
+		if err := rows.Scan(&workerID, &amountStr); err != nil {
+			return nil, fmt.Errorf("pending balances scan: %w", err)
+		}
+		amount, ok := new(big.Int).SetString(amountStr, 10)
+		if !ok {
+			amount = big.NewInt(0)
+		}
+		out[workerID] = amount
+	}
+	return out, rows.Err()
+}
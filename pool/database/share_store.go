@@ -33,3 +33,42 @@ func (p *PostgresShareStore) Save(s core.Share) error {
 	)
 	return err
 }
+
+// SaveIfAbsent delegates to the wrapped Postgres, which already owns the
+// atomic INSERT ... ON CONFLICT DO NOTHING RETURNING xmax + retry logic.
+func (p *PostgresShareStore) SaveIfAbsent(s core.Share) (bool, error) {
+	return p.db.SaveIfAbsent(s)
+}
+
+// SaveShare satisfies pool/http's ShareSaver interface, so handlers that
+// type-assert a core.ShareStore for it find a real implementation here
+// instead of silently no-opping.
+func (p *PostgresShareStore) SaveShare(ctx context.Context, s *core.Share) error {
+	const query = `
+		INSERT INTO shares (id, job_id, worker_id, nonce, hash, difficulty, timestamp, ip)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (id) DO NOTHING
+	`
+	_, err := p.db.DB.ExecContext(
+		ctx,
+		query,
+		s.ID, s.JobID, s.WorkerID, s.Nonce, s.Hash, s.Diff, s.Timestamp, s.IP,
+	)
+	return err
+}
+
+// PostgresPoolStore combines share dedup/persistence and per-miner
+// balance/lease tracking behind the single concrete type Container wires
+// into core.Pool.ShareStore, so the ShareSaver and BalanceStore
+// type-assertions in pool/http's handlers succeed against a real backend.
+type PostgresPoolStore struct {
+	*PostgresShareStore
+	*PostgresBalanceStore
+}
+
+func NewPostgresPoolStore(pg *Postgres) *PostgresPoolStore {
+	return &PostgresPoolStore{
+		PostgresShareStore:   NewPostgresShareStore(pg),
+		PostgresBalanceStore: NewPostgresBalanceStore(pg),
+	}
+}
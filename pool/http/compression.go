@@ -0,0 +1,244 @@
+package http
+
+import (
+	"bufio"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+
+	"pool/config"
+)
+
+// compressibleEncoding identifies one negotiable Content-Encoding,
+// paired with the Config flag that can disable it and a pool of
+// reusable encoder instances so a hot path doesn't allocate one per
+// request.
+type compressibleEncoding struct {
+	name      string
+	enabled   func(cfg *config.Config) bool
+	newWriter func(w io.Writer) encoderWriter
+	pool      *sync.Pool
+}
+
+// encoderWriter is the common surface of gzip.Writer, brotli.Writer and
+// zstd.Encoder that compression.go needs: write compressed bytes, reset
+// onto a new underlying writer for pool reuse, and flush/close it.
+type encoderWriter interface {
+	io.WriteCloser
+	Reset(w io.Writer)
+}
+
+// negotiatedEncodings is tried in preference order: zstd and brotli
+// compress better than gzip, so they're offered first when the client
+// supports them.
+var negotiatedEncodings = []*compressibleEncoding{
+	{
+		name:    "zstd",
+		enabled: func(cfg *config.Config) bool { return cfg.Compression.ZstdEnabled },
+		newWriter: func(w io.Writer) encoderWriter {
+			enc, _ := zstd.NewWriter(w, zstd.WithEncoderLevel(zstd.SpeedDefault))
+			return enc
+		},
+		pool: &sync.Pool{},
+	},
+	{
+		name:    "br",
+		enabled: func(cfg *config.Config) bool { return cfg.Compression.BrotliEnabled },
+		newWriter: func(w io.Writer) encoderWriter {
+			return brotli.NewWriter(w)
+		},
+		pool: &sync.Pool{},
+	},
+	{
+		name:    "gzip",
+		enabled: func(cfg *config.Config) bool { return cfg.Compression.GzipEnabled },
+		newWriter: func(w io.Writer) encoderWriter {
+			gw, _ := gzip.NewWriterLevel(w, gzip.DefaultCompression)
+			return gw
+		},
+		pool: &sync.Pool{},
+	},
+}
+
+// skipCompressionContentTypes lists prefixes of Content-Type values
+// that are already compressed (or gain nothing from compression), so
+// withCompression leaves them alone.
+var skipCompressionContentTypes = []string{
+	"image/",
+	"video/",
+	"audio/",
+	"application/zip",
+	"application/gzip",
+	"application/octet-stream",
+}
+
+// negotiateEncoding picks the best encoding both the client (via
+// Accept-Encoding) and cfg (via per-algorithm enable flags) support,
+// or nil if none match.
+func negotiateEncoding(acceptEncoding string, cfg *config.Config) *compressibleEncoding {
+	accepted := make(map[string]bool)
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		name := strings.TrimSpace(strings.SplitN(part, ";", 2)[0])
+		if name != "" {
+			accepted[name] = true
+		}
+	}
+
+	for _, enc := range negotiatedEncodings {
+		if accepted[enc.name] && enc.enabled(cfg) {
+			return enc
+		}
+	}
+	return nil
+}
+
+// withCompression negotiates Accept-Encoding and transparently
+// compresses JSON responses above getCfg().Compression.MinSizeBytes.
+// getCfg is read fresh on every request so a config reload changes
+// compression behavior without restarting the server.
+func withCompression(getCfg func() *config.Config) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			cfg := getCfg()
+			if !cfg.Compression.Enabled {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			enc := negotiateEncoding(r.Header.Get("Accept-Encoding"), cfg)
+			w.Header().Add("Vary", "Accept-Encoding")
+			if enc == nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			cw := &compressingWriter{
+				ResponseWriter: w,
+				enc:            enc,
+				minSize:        cfg.Compression.MinSizeBytes,
+			}
+			defer cw.Close()
+			next.ServeHTTP(cw, r)
+		})
+	}
+}
+
+// compressingWriter buffers the response until it either reaches
+// minSize (at which point it commits to compressing) or the handler
+// finishes writing a smaller payload (at which point it's flushed
+// uncompressed), since compressing tiny responses costs more than it
+// saves.
+type compressingWriter struct {
+	http.ResponseWriter
+	enc     *compressibleEncoding
+	minSize int
+
+	status     int
+	headerSent bool
+	buf        []byte
+	compressed bool
+	compressor encoderWriter
+}
+
+func (cw *compressingWriter) WriteHeader(status int) {
+	cw.status = status
+}
+
+func (cw *compressingWriter) Write(p []byte) (int, error) {
+	if cw.compressed {
+		return cw.compressor.Write(p)
+	}
+
+	if shouldSkipCompression(cw.Header().Get("Content-Type")) {
+		cw.flushRaw()
+		return cw.ResponseWriter.Write(p)
+	}
+
+	cw.buf = append(cw.buf, p...)
+	if len(cw.buf) < cw.minSize {
+		return len(p), nil
+	}
+
+	cw.startCompressing()
+	return len(p), nil
+}
+
+// startCompressing commits to a compressed response: it sets
+// Content-Encoding, drops the stale Content-Length (the compressed
+// size is different and not known up front), acquires a pooled
+// encoder, and flushes the buffered prefix through it.
+func (cw *compressingWriter) startCompressing() {
+	cw.Header().Set("Content-Encoding", cw.enc.name)
+	cw.Header().Del("Content-Length")
+	cw.sendHeader()
+
+	if v := cw.enc.pool.Get(); v != nil {
+		cw.compressor = v.(encoderWriter)
+		cw.compressor.Reset(cw.ResponseWriter)
+	} else {
+		cw.compressor = cw.enc.newWriter(cw.ResponseWriter)
+	}
+	cw.compressed = true
+
+	cw.compressor.Write(cw.buf)
+	cw.buf = nil
+}
+
+// flushRaw commits to an uncompressed response, writing whatever
+// header and buffered bytes have accumulated so far.
+func (cw *compressingWriter) flushRaw() {
+	cw.sendHeader()
+	if len(cw.buf) > 0 {
+		cw.ResponseWriter.Write(cw.buf)
+		cw.buf = nil
+	}
+}
+
+func (cw *compressingWriter) sendHeader() {
+	if cw.headerSent {
+		return
+	}
+	cw.headerSent = true
+	if cw.status == 0 {
+		cw.status = http.StatusOK
+	}
+	cw.ResponseWriter.WriteHeader(cw.status)
+}
+
+// Close finalizes the response: if the payload never reached minSize,
+// it's flushed uncompressed; otherwise the compressor is closed (which
+// flushes its trailer) and returned to its pool for reuse.
+func (cw *compressingWriter) Close() {
+	if !cw.compressed {
+		cw.flushRaw()
+		return
+	}
+	cw.compressor.Close()
+	cw.enc.pool.Put(cw.compressor)
+}
+
+// Hijack lets websocket/streaming handlers bypass the compressing
+// writer if the underlying ResponseWriter supports it.
+func (cw *compressingWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hj, ok := cw.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, fmt.Errorf("http: ResponseWriter não suporta Hijack")
+	}
+	return hj.Hijack()
+}
+
+func shouldSkipCompression(contentType string) bool {
+	for _, prefix := range skipCompressionContentTypes {
+		if strings.HasPrefix(contentType, prefix) {
+			return true
+		}
+	}
+	return false
+}
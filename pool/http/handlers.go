@@ -7,6 +7,7 @@ import (
 	"net/http"
 	"time"
 
+	"pool"
 	"pool/core"
 	"pool/logs"
 	"pool/metrics"
@@ -33,24 +34,39 @@ type PayoutRequest struct {
 	Amount string `json:"amount"`
 }
 
-type RewardSender interface {
-	SendReward(to string, amount *big.Int) (string, error)
-}
-
-var paymentClient RewardSender
-
-func SetPaymentClient(rs RewardSender) {
-	paymentClient = rs
-}
-
 type ShareSaver interface {
 	SaveShare(ctx context.Context, s *core.Share) error
 }
 
+// LockToken identifies a held lease returned by BalanceStore.AcquireLock.
+// It is opaque to callers; implementations typically encode the miner ID,
+// holder ID, and expiry so RefreshLock/ReleaseLock can compare-and-swap
+// against the lease currently on record instead of blindly trusting the
+// caller. Defined as an alias of core.LockToken so pool/database's
+// Postgres-backed BalanceStore can return one without importing this
+// package.
+type LockToken = core.LockToken
+
 type BalanceStore interface {
 	AddBalance(ctx context.Context, minerID string, delta float64) error
 	GetBalance(ctx context.Context, minerID string) (float64, error)
 	ResetBalance(ctx context.Context, minerID string) error
+
+	// AcquireLock takes an exclusive lease on minerID's balance, held by
+	// holderID, so concurrent credits and payout debits for the same
+	// miner can't interleave. The lease expires after ttl even if the
+	// holder crashes before calling ReleaseLock.
+	AcquireLock(ctx context.Context, minerID, holderID string, ttl time.Duration) (LockToken, error)
+
+	// RefreshLock extends a held lease's TTL, but only if token still
+	// matches the lease currently on record (compare-and-swap); a lease
+	// that already expired and was reclaimed by another holder returns an
+	// error instead of silently extending someone else's lock.
+	RefreshLock(ctx context.Context, token LockToken) error
+
+	// ReleaseLock gives up a held lease early instead of waiting for it
+	// to expire.
+	ReleaseLock(ctx context.Context, token LockToken) error
 }
 
 func respond(ctx context.Context, w http.ResponseWriter, code int, data StandardResponse) {
@@ -59,7 +75,7 @@ func respond(ctx context.Context, w http.ResponseWriter, code int, data Standard
 	_ = json.NewEncoder(w).Encode(data)
 }
 
-func SubmitShareHandler(pool *core.Pool) http.HandlerFunc {
+func SubmitShareHandler() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		ctx := r.Context()
 		reqID := utils.GetRequestID(ctx)
@@ -78,6 +94,16 @@ func SubmitShareHandler(pool *core.Pool) http.HandlerFunc {
 			return
 		}
 
+		c, ok := pool.FromContext(ctx)
+		if !ok {
+			logger.Error("Pool container not present on request context")
+			respond(ctx, w, http.StatusInternalServerError, StandardResponse{
+				Status:  "error",
+				Message: "Server not ready",
+			})
+			return
+		}
+
 		var payload SharePayload
 		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
 			logger.Warn("Invalid JSON structure")
@@ -97,7 +123,7 @@ func SubmitShareHandler(pool *core.Pool) http.HandlerFunc {
 			return
 		}
 
-		if security.IsFraudulentNonce(payload.MinerID, payload.Nonce) {
+		if security.IsFraudulentNonce(ctx, payload.MinerID, payload.Nonce) {
 			logger.Warn("Suspicious share blocked")
 			metrics.SharesInvalid.Inc()
 			respond(ctx, w, http.StatusForbidden, StandardResponse{
@@ -116,9 +142,10 @@ func SubmitShareHandler(pool *core.Pool) http.HandlerFunc {
 			Timestamp: time.Now(),
 		}
 
-		processor := core.NewShareProcessor(core.NewDefaultShareValidator(pool.ShareStore), pool.ShareStore)
+		processor := core.NewShareProcessor(core.NewDefaultShareValidator(c.ShareStore), c.ShareStore)
+		processor.Consensus = c.Consensus
 		start := time.Now()
-		result := processor.Process(*share, "0000", 30*time.Second)
+		result := processor.ProcessContext(ctx, *share, "0000", 30*time.Second)
 		metrics.ValidationDuration.Observe(time.Since(start).Seconds())
 
 		if !result.Valid {
@@ -131,7 +158,7 @@ func SubmitShareHandler(pool *core.Pool) http.HandlerFunc {
 			return
 		}
 
-		if err := pool.Engine.SubmitShare(core.WorkerIdentifier(payload.MinerID), share); err != nil {
+		if err := c.Pool.Engine.SubmitShare(core.WorkerIdentifier(payload.MinerID), share); err != nil {
 			logger.Error("Failed to submit share to engine: " + err.Error())
 			respond(ctx, w, http.StatusInternalServerError, StandardResponse{
 				Status:  "error",
@@ -140,15 +167,24 @@ func SubmitShareHandler(pool *core.Pool) http.HandlerFunc {
 			return
 		}
 
-		if saver, ok := pool.ShareStore.(ShareSaver); ok {
+		if saver, ok := c.ShareStore.(ShareSaver); ok {
 			if err := saver.SaveShare(ctx, share); err != nil {
 				logger.Error("Failed to save share to DB: " + err.Error())
 			}
 		}
 
-		if bal, ok := pool.ShareStore.(BalanceStore); ok {
-			if err := bal.AddBalance(ctx, payload.MinerID, payload.HashRate); err != nil {
-				logger.Error("Failed to update miner balance: " + err.Error())
+		if bal, ok := c.ShareStore.(BalanceStore); ok {
+			token, err := bal.AcquireLock(ctx, payload.MinerID, reqID, 5*time.Second)
+			if err != nil {
+				metrics.BalanceLockContention.Inc()
+				logger.Error("Failed to acquire balance lock: " + err.Error())
+			} else {
+				if err := bal.AddBalance(ctx, payload.MinerID, payload.HashRate); err != nil {
+					logger.Error("Failed to update miner balance: " + err.Error())
+				}
+				if err := bal.ReleaseLock(ctx, token); err != nil {
+					logger.Error("Failed to release balance lock: " + err.Error())
+				}
 			}
 		}
 
@@ -180,7 +216,12 @@ func GetPoolStatsHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	stats := core.GetCurrentPoolStats()
+	var engine *core.PoolCore
+	if c, ok := pool.FromContext(ctx); ok {
+		engine = c.Pool.Engine
+	}
+
+	stats := core.GetCurrentPoolStats(engine)
 	logger.Info("Pool stats retrieved")
 	respond(ctx, w, http.StatusOK, StandardResponse{
 		Status: "success",
@@ -207,7 +248,18 @@ func TestPayoutHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if paymentClient == nil {
+	c, ok := pool.FromContext(ctx)
+	if !ok {
+		logger.Error("Pool container not found in context")
+		respond(ctx, w, http.StatusInternalServerError, StandardResponse{
+			Status:  "error",
+			Message: "Erro interno",
+		})
+		return
+	}
+
+	dryRun := c.Config.Payout.DryRun
+	if !dryRun && c.PaymentClient == nil {
 		logger.Error("Payment client not initialized")
 		respond(ctx, w, http.StatusInternalServerError, StandardResponse{
 			Status:  "error",
@@ -236,17 +288,52 @@ func TestPayoutHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	txHash, err := paymentClient.SendReward(req.To, amount)
-	if err != nil {
-		logger.Error("Payout transaction failed: " + err.Error())
-		respond(ctx, w, http.StatusInternalServerError, StandardResponse{
-			Status:  "error",
-			Message: err.Error(),
-		})
-		return
+	// Hold the miner's balance lock across the whole read-then-pay
+	// sequence so a concurrent share credit can't land between the
+	// balance check and SendReward, and release it only once the
+	// transaction has either succeeded or been confirmed failed.
+	if bal, ok := c.ShareStore.(BalanceStore); ok {
+		token, err := bal.AcquireLock(ctx, req.To, reqID, 10*time.Second)
+		if err != nil {
+			metrics.BalanceLockContention.Inc()
+			logger.Error("Failed to acquire balance lock: " + err.Error())
+			respond(ctx, w, http.StatusConflict, StandardResponse{
+				Status:  "error",
+				Message: "Payout already in progress for this miner",
+			})
+			return
+		}
+		defer func() {
+			if err := bal.ReleaseLock(ctx, token); err != nil {
+				logger.Error("Failed to release balance lock: " + err.Error())
+			}
+		}()
+
+		if balance, err := bal.GetBalance(ctx, req.To); err != nil {
+			logger.Error("Failed to read miner balance: " + err.Error())
+		} else {
+			logger.WithFields(map[string]interface{}{"balance": balance}).Info("Miner balance read for payout")
+		}
+	}
+
+	var txHash string
+	if dryRun {
+		txHash = "dryrun-" + reqID
+		logger.Info("Dry-run payout, no real transaction sent")
+	} else {
+		var err error
+		txHash, err = c.PaymentClient.SendReward(req.To, amount)
+		if err != nil {
+			logger.Error("Payout transaction failed: " + err.Error())
+			respond(ctx, w, http.StatusInternalServerError, StandardResponse{
+				Status:  "error",
+				Message: err.Error(),
+			})
+			return
+		}
+		logger.Info("Payout transaction sent successfully")
 	}
 
-	logger.Info("Payout transaction sent successfully")
 	respond(ctx, w, http.StatusOK, StandardResponse{
 		Status: "success",
 		Data:   map[string]string{"txHash": txHash},
@@ -3,17 +3,39 @@ package http
 import (
 	"context"
 	"fmt"
+	"net"
 	"net/http"
 	"strings"
+	"sync"
 	"time"
 
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"golang.org/x/time/rate"
+
+	"pool"
+	"pool/config"
 	"pool/logs"
+	"pool/security"
+	"pool/telemetry"
 	"pool/utils"
 )
 
 // Assinatura de middleware
 type Middleware func(http.Handler) http.Handler
 
+// Middleware: injeta o pool.Container no contexto da requisição, para
+// que handlers leiam suas dependências via pool.FromContext em vez de
+// globais a nível de pacote.
+func withContainer(c *pool.Container) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := pool.NewContext(r.Context(), c)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
 // Aplica a cadeia de middlewares
 func ApplyMiddleware(h http.Handler, chain ...Middleware) http.Handler {
 	for i := len(chain) - 1; i >= 0; i-- {
@@ -31,10 +53,58 @@ func withRequestID(next http.Handler) http.Handler {
 	})
 }
 
-// Middleware: adiciona timeout por requisição
-func withTimeout(timeout time.Duration) Middleware {
+// Middleware: inicia um span de servidor por requisição. Deve vir depois
+// de withRequestID na cadeia, assim o span carrega o request_id e o log
+// estruturado consegue ler trace_id/span_id de volta do contexto.
+func withTracing(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, span := telemetry.Tracer().Start(r.Context(), r.Method+" "+r.URL.Path)
+		defer span.End()
+
+		span.SetAttributes(
+			attribute.String("http.method", r.Method),
+			attribute.String("http.target", r.URL.Path),
+			attribute.String("request_id", utils.GetRequestID(ctx)),
+		)
+
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r.WithContext(ctx))
+
+		span.SetAttributes(attribute.Int("http.status_code", rec.status))
+		if rec.status >= 500 {
+			span.SetStatus(codes.Error, http.StatusText(rec.status))
+		}
+	})
+}
+
+// statusRecorder captures the status code written by downstream handlers
+// so withTracing can attach it to the span after ServeHTTP returns.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(code int) {
+	r.status = code
+	r.ResponseWriter.WriteHeader(code)
+}
+
+// withTimeout adds a per-request timeout. getCfg().HTTP.Routes[path]'s
+// Timeout overrides defaultTimeout when the route has an entry; zero
+// (whether from an override or, with no entry at all, from
+// defaultTimeout itself) disables the timeout entirely, which is how an
+// operator exempts e.g. /metrics from it without recompiling.
+func withTimeout(getCfg func() *config.Config, defaultTimeout time.Duration) Middleware {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			timeout := defaultTimeout
+			if route, ok := getCfg().HTTP.Routes[r.URL.Path]; ok {
+				timeout = route.Timeout
+			}
+			if timeout <= 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
 			ctx, cancel := context.WithTimeout(r.Context(), timeout)
 			defer cancel()
 			next.ServeHTTP(w, r.WithContext(ctx))
@@ -42,6 +112,86 @@ func withTimeout(timeout time.Duration) Middleware {
 	}
 }
 
+// withRouteRateLimit caps each route with a configured RateLimitRPS to
+// that many requests/second per client IP, using a token bucket sized to
+// one second of burst. Limiters are created lazily per (route, IP) pair
+// and never evicted; a pool with a very large number of distinct client
+// IPs hitting a rate-limited route will grow this map accordingly.
+func withRouteRateLimit(getCfg func() *config.Config) Middleware {
+	var mu sync.Mutex
+	limiters := make(map[string]*rate.Limiter)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			route, ok := getCfg().HTTP.Routes[r.URL.Path]
+			if !ok || route.RateLimitRPS <= 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			key := r.URL.Path + "|" + clientIP(r)
+
+			mu.Lock()
+			lim, exists := limiters[key]
+			if !exists {
+				burst := int(route.RateLimitRPS)
+				if burst < 1 {
+					burst = 1
+				}
+				lim = rate.NewLimiter(rate.Limit(route.RateLimitRPS), burst)
+				limiters[key] = lim
+			}
+			mu.Unlock()
+
+			if !lim.Allow() {
+				http.Error(w, "Too Many Requests", http.StatusTooManyRequests)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// withRouteCIDR restricts a route with configured AllowCIDRs to client
+// IPs within one of those blocks, rejecting everything else with 403. A
+// route with no entry, or an entry with an empty AllowCIDRs, is left
+// unrestricted.
+func withRouteCIDR(getCfg func() *config.Config) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			route, ok := getCfg().HTTP.Routes[r.URL.Path]
+			if !ok || len(route.AllowCIDRs) == 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			ip := clientIP(r)
+			for _, cidr := range route.AllowCIDRs {
+				if allowed, err := utils.IsIPAllowed(ip, cidr); err == nil && allowed {
+					next.ServeHTTP(w, r)
+					return
+				}
+			}
+
+			logs.WithFields(map[string]interface{}{
+				"remote": r.RemoteAddr,
+				"path":   r.URL.Path,
+			}).Warn("Forbidden: source IP outside configured allowlist")
+			http.Error(w, "Forbidden", http.StatusForbidden)
+		})
+	}
+}
+
+// clientIP strips the port off r.RemoteAddr, falling back to the raw
+// value if it isn't a host:port pair (e.g. behind some test transports).
+func clientIP(r *http.Request) string {
+	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return ip
+}
+
 // Middleware: cabeçalhos de segurança padrão
 func withSecurityHeaders(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -60,7 +210,7 @@ func withLogging(next http.Handler) http.Handler {
 		next.ServeHTTP(w, r)
 		duration := time.Since(start)
 
-		logs.WithFields(map[string]interface{}{
+		logs.FromContext(r.Context()).WithFields(map[string]interface{}{
 			"method":     r.Method,
 			"path":       r.URL.Path,
 			"remote":     r.RemoteAddr,
@@ -73,17 +223,21 @@ func withLogging(next http.Handler) http.Handler {
 // Middleware: CORS
 // - Se origins contiver "*", libera geral com "Access-Control-Allow-Origin: *"
 // - Caso contrário, reflete o Origin somente se ele estiver na lista
-func withCORS(origins []string) Middleware {
-	allowAll := false
-	for _, o := range origins {
-		if o == "*" {
-			allowAll = true
-			break
-		}
-	}
-
+//
+// origins is read fresh on every request via getOrigins so a config
+// reload takes effect without restarting the HTTP server.
+func withCORS(getOrigins func() []string) Middleware {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			origins := getOrigins()
+			allowAll := false
+			for _, o := range origins {
+				if o == "*" {
+					allowAll = true
+					break
+				}
+			}
+
 			origin := r.Header.Get("Origin")
 
 			// Evitar problemas de cache intermediário
@@ -119,20 +273,41 @@ func withCORS(origins []string) Middleware {
 	}
 }
 
-// Middleware: verificação de token (libera /health e /metrics e OPTIONS)
-func withAuthToken(expectedToken string) Middleware {
+// Middleware: autenticação via JWT (libera /health, /metrics, /jwks.json
+// e OPTIONS)
+//
+// Tokens are validated against verifier's current key set (RS256, with
+// exp/nbf/iss/aud checked by the verifier itself); on success the parsed
+// claims are attached to the request context via utils.SetClaims so
+// downstream handlers and RequireScope can read them back.
+//
+// getLegacyBearer, when its bool return is true, allows the request
+// through on a matching static bearer token instead of a JWT — an
+// escape hatch for operators migrating miners over gradually. Remove
+// the AUTH_LEGACY_BEARER fallback once all miners carry JWTs.
+//
+// getCfg().HTTP.Routes[path].AuthScheme == "none" skips authentication
+// for that route entirely, the same as the hardcoded skipAuth paths
+// below, but configurable without recompiling.
+func withJWTAuth(getCfg func() *config.Config, verifier security.Verifier, getLegacyBearer func() (string, bool)) Middleware {
 	skipAuth := map[string]bool{
-		"/health":  true,
-		"/metrics": true,
+		"/health":    true,
+		"/metrics":   true,
+		"/jwks.json": true,
 	}
 
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			// Libera endpoints públicos e preflight
+			// Libera endpoints públicos, preflight e rotas configuradas
+			// com auth_scheme "none".
 			if r.Method == http.MethodOptions || skipAuth[r.URL.Path] {
 				next.ServeHTTP(w, r)
 				return
 			}
+			if route, ok := getCfg().HTTP.Routes[r.URL.Path]; ok && route.AuthScheme == "none" {
+				next.ServeHTTP(w, r)
+				return
+			}
 
 			const prefix = "Bearer "
 			authHeader := r.Header.Get("Authorization")
@@ -144,14 +319,52 @@ func withAuthToken(expectedToken string) Middleware {
 				http.Error(w, "Unauthorized", http.StatusUnauthorized)
 				return
 			}
-
 			token := strings.TrimPrefix(authHeader, prefix)
-			if token != expectedToken {
+
+			claims, err := verifier.Verify(token)
+			if err == nil {
+				ctx := utils.SetClaims(r.Context(), claims)
+				next.ServeHTTP(w, r.WithContext(ctx))
+				return
+			}
+
+			if legacyToken, enabled := getLegacyBearer(); enabled && token == legacyToken {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			logs.WithFields(map[string]interface{}{
+				"remote": r.RemoteAddr,
+				"path":   r.URL.Path,
+				"error":  err.Error(),
+			}).Warn("Unauthorized access attempt (invalid token)")
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		})
+	}
+}
+
+// RequireScope gates a handler to requests whose JWT claims (attached by
+// withJWTAuth) carry the given scope. A request that authenticated via
+// the AUTH_LEGACY_BEARER fallback carries no claims at all and is
+// allowed through unconditionally, an all-or-nothing grant for as long
+// as that fallback stays enabled.
+func RequireScope(scope string) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			raw := utils.GetClaims(r.Context())
+			if raw == nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			claims, ok := raw.(*security.Claims)
+			if !ok || !claims.HasScope(scope) {
 				logs.WithFields(map[string]interface{}{
 					"remote": r.RemoteAddr,
 					"path":   r.URL.Path,
-				}).Warn("Unauthorized access attempt (invalid token)")
-				http.Error(w, "Unauthorized", http.StatusUnauthorized)
+					"scope":  scope,
+				}).Warn("Forbidden: missing required scope")
+				http.Error(w, "Forbidden", http.StatusForbidden)
 				return
 			}
 
@@ -0,0 +1,78 @@
+package http
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"pool"
+)
+
+// pendingBalance is the wire shape for one entry of /payouts/pending.
+type pendingBalance struct {
+	WorkerID string `json:"worker_id"`
+	Amount   string `json:"amount_wei"`
+}
+
+// PendingPayoutsHandler serves the balance every worker is owed but
+// hasn't yet crossed the payout threshold for.
+func PendingPayoutsHandler(w http.ResponseWriter, r *http.Request) (interface{}, error) {
+	c, ok := pool.FromContext(r.Context())
+	if !ok || c.Payout == nil {
+		return nil, newHTTPError(http.StatusServiceUnavailable, "Payout manager not initialized")
+	}
+
+	balances, err := c.Payout.Pending()
+	if err != nil {
+		return nil, newHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	out := make([]pendingBalance, 0, len(balances))
+	for workerID, amount := range balances {
+		out = append(out, pendingBalance{WorkerID: workerID, Amount: amount.String()})
+	}
+	return out, nil
+}
+
+// payoutRecord is the wire shape for one entry of /payouts/history.
+type payoutRecord struct {
+	WorkerID string `json:"worker_id"`
+	Amount   string `json:"amount_wei"`
+	TxHash   string `json:"tx_hash"`
+	PaidAt   string `json:"paid_at"`
+}
+
+// PayoutHistoryHandler serves the most recent completed payouts, newest
+// first. The limit query param caps how many rows come back, defaulting
+// to 100.
+func PayoutHistoryHandler(w http.ResponseWriter, r *http.Request) (interface{}, error) {
+	c, ok := pool.FromContext(r.Context())
+	if !ok || c.Payout == nil {
+		return nil, newHTTPError(http.StatusServiceUnavailable, "Payout manager not initialized")
+	}
+
+	limit := 100
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed <= 0 {
+			return nil, newHTTPError(http.StatusBadRequest, "Invalid limit")
+		}
+		limit = parsed
+	}
+
+	history, err := c.Payout.History(limit)
+	if err != nil {
+		return nil, newHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	out := make([]payoutRecord, 0, len(history))
+	for _, rec := range history {
+		out = append(out, payoutRecord{
+			WorkerID: rec.WorkerID,
+			Amount:   rec.Amount.String(),
+			TxHash:   rec.TxHash,
+			PaidAt:   rec.PaidAt.Format(time.RFC3339),
+		})
+	}
+	return out, nil
+}
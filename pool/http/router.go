@@ -5,11 +5,14 @@ import (
 	"net/http"
 	"time"
 
-	"pool/core"
+	"pool"
 )
 
-// NewRouter cria um router HTTP com todas as rotas registradas
-func NewRouter(pool *core.Pool) http.Handler {
+// NewRouter cria um router HTTP com todas as rotas registradas.
+// Handlers read their dependencies (engine, payment client, ...) from
+// the request's pool.Container via context, injected by withContainer,
+// rather than taking them as constructor arguments.
+func NewRouter() http.Handler {
 	mux := http.NewServeMux()
 
 	// Health check
@@ -17,10 +20,26 @@ func NewRouter(pool *core.Pool) http.Handler {
 		return map[string]string{"status": "ok", "timestamp": time.Now().Format(time.RFC3339)}, nil
 	}))
 
+	// Publica as chaves públicas atuais para que verificadores externos
+	// consigam validar os JWTs emitidos por pool.Container.Auth.
+	mux.HandleFunc("/jwks.json", withJSON(func(w http.ResponseWriter, r *http.Request) (interface{}, error) {
+		c, ok := pool.FromContext(r.Context())
+		if !ok {
+			return nil, newHTTPError(http.StatusInternalServerError, "Erro interno")
+		}
+		return c.Auth.JWKS(), nil
+	}))
+
 	// Rotas principais usando os handlers já implementados
-	mux.Handle("/submit", SubmitShareHandler(pool))   // POST
-	mux.HandleFunc("/stats", GetPoolStatsHandler)     // GET
-	mux.HandleFunc("/test-payout", TestPayoutHandler) // POST
+	mux.Handle("/submit", ApplyMiddleware(SubmitShareHandler(), RequireScope("share:submit")))                     // POST
+	mux.HandleFunc("/stats", GetPoolStatsHandler)                                                                  // GET
+	mux.Handle("/test-payout", ApplyMiddleware(http.HandlerFunc(TestPayoutHandler), RequireScope("payout:write"))) // POST
+
+	mux.Handle("/payouts/pending", ApplyMiddleware(withJSON(PendingPayoutsHandler), RequireScope("payout:read"))) // GET
+	mux.Handle("/payouts/history", ApplyMiddleware(withJSON(PayoutHistoryHandler), RequireScope("payout:read")))  // GET
+
+	mux.Handle("/security/bans", ApplyMiddleware(withJSON(ListBansHandler), RequireScope("security:read")))        // GET
+	mux.Handle("/security/bans/", ApplyMiddleware(http.HandlerFunc(UnbanHandler), RequireScope("security:write"))) // POST /security/bans/:id
 
 	// (Opcional) Rota legada /shares apenas para placeholder
 	mux.HandleFunc("/shares", func(w http.ResponseWriter, r *http.Request) {
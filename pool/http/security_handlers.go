@@ -0,0 +1,48 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"pool"
+)
+
+// ListBansHandler serves every worker the antifraud Detector currently
+// has a ban record for (including ones whose ban has already expired).
+func ListBansHandler(w http.ResponseWriter, r *http.Request) (interface{}, error) {
+	c, ok := pool.FromContext(r.Context())
+	if !ok || c.FraudDetector == nil {
+		return nil, newHTTPError(http.StatusServiceUnavailable, "Fraud detector not initialized")
+	}
+	return c.FraudDetector.Bans(), nil
+}
+
+// UnbanHandler lifts a worker's ban early. The worker ID is the path
+// segment after /security/bans/, e.g. POST /security/bans/worker-123.
+func UnbanHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	workerID := strings.TrimPrefix(r.URL.Path, "/security/bans/")
+	if workerID == "" {
+		http.Error(w, "Missing worker id", http.StatusBadRequest)
+		return
+	}
+
+	c, ok := pool.FromContext(r.Context())
+	if !ok || c.FraudDetector == nil {
+		http.Error(w, "Fraud detector not initialized", http.StatusServiceUnavailable)
+		return
+	}
+
+	if !c.FraudDetector.Unban(workerID) {
+		http.Error(w, "Worker has no ban on record", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(StandardResponse{Status: "success", Message: "Worker unbanned"})
+}
@@ -6,8 +6,10 @@ import (
 	"net/http"
 	"os"
 	"strings"
+	"sync/atomic"
 	"time"
 
+	"pool"
 	"pool/config"
 	"pool/logs"
 	"pool/metrics"
@@ -15,53 +17,88 @@ import (
 
 // Server estrutura o servidor HTTP
 type Server struct {
-	engine         *http.Server
-	cfg            *config.Config
-	handler        http.Handler
-	allowedOrigins []string
+	engine  *http.Server
+	cfg     *config.Config
+	handler atomic.Value // stores http.Handler
 }
 
-// NewServer aplica os middlewares e monta o servidor
-func NewServer(cfg *config.Config, router http.Handler) *Server {
-	// Inicializa métricas Prometheus
-	metrics.InitRegistry()
+// ServeHTTP lets Server itself be used as http.Handler, delegating to
+// whichever handler chain is currently stored in s.handler. Routing
+// through this indirection (rather than handing engine.Handler the chain
+// built at construction time directly) is what lets buildHandler swap in
+// a freshly built chain on every config reload without restarting the
+// listener.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.handler.Load().(http.Handler).ServeHTTP(w, r)
+}
+
+// NewServer aplica os middlewares e monta o servidor. cfg is read once
+// for fields that only matter at listen time (host/port); everything
+// that can change without a restart is read live on every request (the
+// legacy bearer token and per-route HTTP.Routes overrides from watcher,
+// allowed origins from the POOL_ALLOWED_ORIGINS environment variable),
+// and the whole middleware chain is rebuilt and atomically swapped in on
+// every accepted config reload via watcher.OnReload. container is
+// injected into every request's context so handlers can reach it via
+// pool.FromContext.
+func NewServer(cfg *config.Config, watcher *config.Watcher, router http.Handler, container *pool.Container) *Server {
+	s := &Server{cfg: cfg}
+	s.handler.Store(buildHandler(watcher, router, container))
+
+	watcher.OnReload(func(*config.Config) {
+		s.handler.Store(buildHandler(watcher, router, container))
+	})
+
+	return s
+}
 
+// buildHandler assembles the full mux + middleware chain. Called once at
+// startup and again on every accepted config reload; watcher.rebuild
+// already validates HTTP.Routes (auth_scheme, CIDRs, rate limits) and
+// rejects a bad reload before OnReload listeners — and so this function —
+// ever run, so buildHandler itself can assume cfg is sound.
+func buildHandler(watcher *config.Watcher, router http.Handler, container *pool.Container) http.Handler {
 	// Cria um mux que combina as rotas normais com /metrics
 	mux := http.NewServeMux()
 	mux.Handle("/", router) // rotas principais da pool
 	mux.Handle("/metrics", metrics.Handler())
 
-	// Carrega origens permitidas de ENV ou config
-	allowedOrigins := []string{"https://seu-dominio.com"}
-	if envVal := os.Getenv("POOL_ALLOWED_ORIGINS"); envVal != "" {
-		allowedOrigins = strings.Split(envVal, ",")
+	getOrigins := func() []string {
+		if envVal := os.Getenv("POOL_ALLOWED_ORIGINS"); envVal != "" {
+			return strings.Split(envVal, ",")
+		}
+		return []string{"https://seu-dominio.com"}
+	}
+	getLegacyBearer := func() (string, bool) {
+		cur := watcher.Current()
+		return cur.Auth.Token, cur.Auth.LegacyBearer
 	}
 
-	// Cadeia de middlewares
+	// Cadeia de middlewares. withRouteCIDR and withRouteRateLimit run
+	// before auth/timeout so a rejected or throttled request never
+	// reaches the more expensive checks below it.
 	middlewares := []Middleware{
 		withRequestID,
+		withContainer(container),
+		withTracing,
 		withSecurityHeaders,
-		withCORS(allowedOrigins),
-		withAuthToken(cfg.Security.APIKey),
-		withTimeout(10 * time.Second),
+		withCORS(getOrigins),
+		withRouteCIDR(watcher.Current),
+		withRouteRateLimit(watcher.Current),
+		withJWTAuth(watcher.Current, container.Auth, getLegacyBearer),
+		withCompression(watcher.Current),
+		withTimeout(watcher.Current, 10*time.Second),
 		withLogging,
 	}
 
-	// Aplicar cadeia
-	finalHandler := ApplyMiddleware(mux, middlewares...)
-
-	return &Server{
-		cfg:            cfg,
-		handler:        finalHandler,
-		allowedOrigins: allowedOrigins,
-	}
+	return ApplyMiddleware(mux, middlewares...)
 }
 
 // Start inicia o servidor HTTP com parâmetros avançados
 func (s *Server) Start() error {
 	s.engine = &http.Server{
 		Addr:              net.JoinHostPort(s.cfg.Server.Host, s.cfg.Server.Port),
-		Handler:           s.handler,
+		Handler:           s,
 		ReadTimeout:       20 * time.Second,
 		ReadHeaderTimeout: 8 * time.Second,
 		WriteTimeout:      30 * time.Second,
@@ -70,9 +107,8 @@ func (s *Server) Start() error {
 	}
 
 	logs.WithFields(map[string]interface{}{
-		"host":            s.cfg.Server.Host,
-		"port":            s.cfg.Server.Port,
-		"allowed_origins": strings.Join(s.allowedOrigins, ","),
+		"host": s.cfg.Server.Host,
+		"port": s.cfg.Server.Port,
 	}).Info("HTTP server starting...")
 
 	return s.engine.ListenAndServe()
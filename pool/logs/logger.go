@@ -1,6 +1,7 @@
 package logs
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"log"
@@ -10,6 +11,10 @@ import (
 	"strings"
 	"sync"
 	"time"
+
+	"go.opentelemetry.io/otel/trace"
+
+	"pool/metrics"
 )
 
 var (
@@ -22,12 +27,131 @@ var (
 const maxMessageSize = 2048
 
 type logMeta struct {
-	Timestamp string `json:"timestamp"`
-	Level     string `json:"level"`
-	File      string `json:"file"`
-	Func      string `json:"func"`
-	Line      int    `json:"line"`
-	Message   string `json:"message"`
+	Timestamp string                 `json:"timestamp"`
+	Level     string                 `json:"level"`
+	File      string                 `json:"file"`
+	Func      string                 `json:"func"`
+	Line      int                    `json:"line"`
+	Message   string                 `json:"message"`
+	Fields    map[string]interface{} `json:"fields,omitempty"`
+}
+
+// tokenBucket is a simple QPS limiter: it accrues `rate` tokens/second up
+// to `burst`, and allow() spends one token per accepted line.
+type tokenBucket struct {
+	mu       sync.Mutex
+	rate     float64
+	burst    float64
+	tokens   float64
+	lastFill time.Time
+}
+
+func newTokenBucket(rate, burst float64) *tokenBucket {
+	return &tokenBucket{rate: rate, burst: burst, tokens: burst, lastFill: time.Now()}
+}
+
+func (tb *tokenBucket) allow() bool {
+	tb.mu.Lock()
+	defer tb.mu.Unlock()
+
+	now := time.Now()
+	tb.tokens += now.Sub(tb.lastFill).Seconds() * tb.rate
+	if tb.tokens > tb.burst {
+		tb.tokens = tb.burst
+	}
+	tb.lastFill = now
+
+	if tb.tokens < 1 {
+		return false
+	}
+	tb.tokens--
+	return true
+}
+
+// levelControl holds the sampling/rate-limit configuration for a single
+// log level, so a busy validator spamming DEBUG lines can be throttled
+// without silencing other levels.
+type levelControl struct {
+	mu sync.Mutex
+
+	// sampleEvery, when >1, emits only 1 of every N lines at this level.
+	sampleEvery uint64
+	counter     uint64
+
+	bucket *tokenBucket
+}
+
+var (
+	controlsMu sync.Mutex
+	controls   = make(map[string]*levelControl)
+)
+
+func controlFor(level string) *levelControl {
+	controlsMu.Lock()
+	defer controlsMu.Unlock()
+	c, ok := controls[level]
+	if !ok {
+		c = &levelControl{}
+		controls[level] = c
+	}
+	return c
+}
+
+// SetSampleRate makes `level` emit only 1 of every n lines, dropping the
+// rest (and counting them via pool_logs_dropped_total). n<=1 disables
+// sampling for that level.
+func SetSampleRate(level string, n int) {
+	c := controlFor(strings.ToUpper(level))
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if n < 1 {
+		n = 1
+	}
+	c.sampleEvery = uint64(n)
+	c.counter = 0
+}
+
+// SetRateLimit caps `level` to qps lines/second with the given burst.
+// qps<=0 removes any limit, which is also the default.
+func SetRateLimit(level string, qps float64, burst int) {
+	c := controlFor(strings.ToUpper(level))
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if qps <= 0 {
+		c.bucket = nil
+		return
+	}
+	if burst < 1 {
+		burst = 1
+	}
+	c.bucket = newTokenBucket(qps, float64(burst))
+}
+
+// shouldEmit applies level's sampling then its rate limit, in that
+// order, incrementing pool_logs_dropped_total for whichever rejects the
+// line first. Checked before any formatting work so a throttled DEBUG
+// level costs little more than the map lookup.
+func shouldEmit(level string) bool {
+	c := controlFor(level)
+	c.mu.Lock()
+	sampleEvery := c.sampleEvery
+	var sampledOut bool
+	if sampleEvery > 1 {
+		c.counter++
+		sampledOut = c.counter%sampleEvery != 0
+	}
+	bucket := c.bucket
+	c.mu.Unlock()
+
+	if sampledOut {
+		metrics.LogsDropped.WithLabelValues(level).Inc()
+		return false
+	}
+	if bucket != nil && !bucket.allow() {
+		metrics.LogsDropped.WithLabelValues(level).Inc()
+		return false
+	}
+	return true
 }
 
 // Init initializes the logger according to the environment
@@ -70,10 +194,13 @@ func SilenceLogs() {
 	silent = true
 }
 
-func logInternal(level string, msg string, args ...any) {
+func logInternal(level string, fields map[string]interface{}, msg string, args ...any) {
 	if silent {
 		return
 	}
+	if !shouldEmit(level) {
+		return
+	}
 
 	mutex.Lock()
 	defer mutex.Unlock()
@@ -103,6 +230,7 @@ func logInternal(level string, msg string, args ...any) {
 			Func:      fnName,
 			Line:      line,
 			Message:   fullMsg,
+			Fields:    fields,
 		}
 		if encoded, err := json.Marshal(entry); err == nil {
 			fmt.Fprintln(os.Stdout, string(encoded))
@@ -116,25 +244,44 @@ func logInternal(level string, msg string, args ...any) {
 	tag := paddedLevel(level)
 	reset := "\033[0m"
 
-	fmt.Printf("%s[%s]%s %s [%s:%d > %s] %s\n",
-		color, tag, reset, timestamp, fileName, line, fnName, fullMsg)
+	fmt.Printf("%s[%s]%s %s [%s:%d > %s] %s%s\n",
+		color, tag, reset, timestamp, fileName, line, fnName, fullMsg, formatFields(fields))
 }
 
-func Debug(msg string, args ...any) { logInternal("DEBUG", msg, args...) }
-func Info(msg string, args ...any)  { logInternal("INFO", msg, args...) }
-func Warn(msg string, args ...any)  { logInternal("WARN", msg, args...) }
-func Error(msg string, args ...any) { logInternal("ERROR", msg, args...) }
+// formatFields renders arbitrary structured fields as trailing key=value
+// pairs for text-mode output. trace_id/request_id/error are skipped here
+// since logEntry.log already renders them into the bracketed prefix.
+// JSON mode carries the full, unfiltered map natively in logMeta.Fields.
+func formatFields(fields map[string]interface{}) string {
+	if len(fields) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	for k, v := range fields {
+		switch k {
+		case "trace_id", "request_id", "error":
+			continue
+		}
+		fmt.Fprintf(&b, " %s=%v", k, v)
+	}
+	return b.String()
+}
+
+func Debug(msg string, args ...any) { logInternal("DEBUG", nil, msg, args...) }
+func Info(msg string, args ...any)  { logInternal("INFO", nil, msg, args...) }
+func Warn(msg string, args ...any)  { logInternal("WARN", nil, msg, args...) }
+func Error(msg string, args ...any) { logInternal("ERROR", nil, msg, args...) }
 func Fatal(msg string, args ...any) {
-	logInternal("FATAL", msg, args...)
+	logInternal("FATAL", nil, msg, args...)
 	os.Exit(1)
 }
 
-func Debugf(format string, args ...any) { logInternal("DEBUG", format, args...) }
-func Infof(format string, args ...any)  { logInternal("INFO", format, args...) }
-func Warnf(format string, args ...any)  { logInternal("WARN", format, args...) }
-func Errorf(format string, args ...any) { logInternal("ERROR", format, args...) }
+func Debugf(format string, args ...any) { logInternal("DEBUG", nil, format, args...) }
+func Infof(format string, args ...any)  { logInternal("INFO", nil, format, args...) }
+func Warnf(format string, args ...any)  { logInternal("WARN", nil, format, args...) }
+func Errorf(format string, args ...any) { logInternal("ERROR", nil, format, args...) }
 func Fatalf(format string, args ...any) {
-	logInternal("FATAL", format, args...)
+	logInternal("FATAL", nil, format, args...)
 	os.Exit(1)
 }
 
@@ -167,6 +314,18 @@ func WithFields(fields map[string]interface{}) *logEntry {
 	return &logEntry{fields: fields}
 }
 
+// FromContext builds a logEntry seeded with the trace_id/span_id of the
+// span active on ctx, if any, so log lines can be correlated with traces
+// in a collector without every call site wiring them in by hand.
+func FromContext(ctx context.Context) *logEntry {
+	fields := map[string]interface{}{}
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		fields["trace_id"] = sc.TraceID().String()
+		fields["span_id"] = sc.SpanID().String()
+	}
+	return &logEntry{fields: fields}
+}
+
 type logEntry struct {
 	fields map[string]interface{}
 }
@@ -178,10 +337,25 @@ func (e *logEntry) WithError(err error) {
 	e.fields["error"] = err.Error()
 }
 
+// WithFields merges additional fields into an existing entry, so callers
+// can start from FromContext(ctx) and still attach request-specific data.
+func (e *logEntry) WithFields(fields map[string]interface{}) *logEntry {
+	if e.fields == nil {
+		e.fields = make(map[string]interface{}, len(fields))
+	}
+	for k, v := range fields {
+		e.fields[k] = v
+	}
+	return e
+}
+
 func (e *logEntry) log(level string, msg string, args ...any) {
 	prefix := ""
+	if traceID, ok := e.fields["trace_id"]; ok {
+		prefix = fmt.Sprintf("[trace:%v] ", traceID)
+	}
 	if id, ok := e.fields["request_id"]; ok {
-		prefix = fmt.Sprintf("[req:%v] ", id)
+		prefix += fmt.Sprintf("[req:%v] ", id)
 	}
 	if errStr, ok := e.fields["error"]; ok && errStr != "" {
 		prefix += fmt.Sprintf("[error:%v] ", errStr)
@@ -190,7 +364,7 @@ func (e *logEntry) log(level string, msg string, args ...any) {
 	formatted := fmt.Sprintf(msg, args...)
 	fullMsg := prefix + formatted
 
-	logInternal(level, fullMsg)
+	logInternal(level, e.fields, fullMsg)
 }
 
 func (e *logEntry) Debug(msg string, args ...any) { e.log("DEBUG", msg, args...) }
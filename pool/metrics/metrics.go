@@ -32,4 +32,80 @@ var (
 		Name: "pool_internal_errors_total",
 		Help: "Total de erros internos no processamento",
 	})
+
+	RetargetCount = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "pool_vardiff_retarget_total",
+		Help: "Total de retargets de dificuldade por worker",
+	}, []string{"worker_id"})
+
+	CurrentDiff = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "pool_vardiff_current_diff",
+		Help: "Dificuldade atual atribuída ao worker",
+	}, []string{"worker_id"})
+
+	SharesPerMinute = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "pool_vardiff_shares_per_minute",
+		Help: "Taxa observada de shares por minuto por worker",
+	}, []string{"worker_id"})
+
+	ConfigReloadTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "pool_config_reload_total",
+		Help: "Total de tentativas de hot-reload de configuração, por resultado",
+	}, []string{"result"})
+
+	LogsDropped = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "pool_logs_dropped_total",
+		Help: "Total de linhas de log descartadas por sampling ou rate limiting, por nível",
+	}, []string{"level"})
+
+	ConsensusOutcome = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "pool_consensus_outcome_total",
+		Help: "Total de respostas de validadores de consenso, por resultado (agreed/disagreed/timeout)",
+	}, []string{"result"})
+
+	BalanceLockContention = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "pool_balance_lock_contention_total",
+		Help: "Total de tentativas de AcquireLock que encontraram o saldo do miner já travado",
+	})
+
+	// BalanceLockExpiredReclaims is incremented by BalanceStore
+	// implementations when AcquireLock reclaims a lease whose TTL expired
+	// before the original holder called ReleaseLock.
+	BalanceLockExpiredReclaims = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "pool_balance_lock_expired_reclaims_total",
+		Help: "Total de leases de saldo reclamados após expiração do holder original",
+	})
+
+	// SnapshotCorrupted is incremented whenever a periodic state snapshot
+	// fails to load or decode, by component (e.g. "jobmanager",
+	// "inspector") — a corrupted snapshot is discarded and started fresh
+	// rather than taking the process down.
+	SnapshotCorrupted = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "pool_snapshot_corrupted_total",
+		Help: "Total de snapshots de estado corrompidos ou parciais descartados no carregamento, por componente",
+	}, []string{"component"})
+
+	PayoutRoundDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "pool_payout_round_duration_seconds",
+		Help:    "Tempo gasto distribuindo a recompensa de um bloco encontrado, por scheme",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"scheme"})
+
+	PayoutOrphanedRounds = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "pool_payout_orphaned_rounds_total",
+		Help: "Total de rounds de payout cujo bloco foi orfanado antes da confirmação, por scheme",
+	}, []string{"scheme"})
+
+	PayoutFeeRevenue = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "pool_payout_fee_revenue_wei_total",
+		Help: "Total de wei retidos como taxa da pool nos payouts distribuídos, por scheme",
+	}, []string{"scheme"})
+
+	// WorkerHashrateEstimate complements CurrentDiff (which already
+	// exposes per-worker difficulty): currentDiff * 2^32 / avgShareInterval,
+	// recomputed on every vardiff retarget.
+	WorkerHashrateEstimate = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "pool_vardiff_hashrate_estimate",
+		Help: "Estimativa de hashrate do worker, derivada da dificuldade atual e do intervalo médio entre shares",
+	}, []string{"worker_id"})
 )
@@ -25,6 +25,19 @@ func InitRegistry() {
 		registry.MustRegister(ValidationDuration)
 		registry.MustRegister(JobsActive)
 		registry.MustRegister(WorkersConnected)
+		registry.MustRegister(RetargetCount)
+		registry.MustRegister(CurrentDiff)
+		registry.MustRegister(SharesPerMinute)
+		registry.MustRegister(ConfigReloadTotal)
+		registry.MustRegister(LogsDropped)
+		registry.MustRegister(ConsensusOutcome)
+		registry.MustRegister(BalanceLockContention)
+		registry.MustRegister(BalanceLockExpiredReclaims)
+		registry.MustRegister(SnapshotCorrupted)
+		registry.MustRegister(PayoutRoundDuration)
+		registry.MustRegister(PayoutOrphanedRounds)
+		registry.MustRegister(PayoutFeeRevenue)
+		registry.MustRegister(WorkerHashrateEstimate)
 	})
 }
 
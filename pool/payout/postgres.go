@@ -0,0 +1,111 @@
+package payout
+
+import (
+	"database/sql"
+	"fmt"
+	"math/big"
+	"time"
+)
+
+// PostgresLedger persists payouts and derives pending balances from the
+// same Postgres connection validation_service/database opens for
+// shares — callers pass that *sql.DB in rather than this package
+// opening its own, so there's a single place (main.go) that owns the
+// connection's lifetime.
+type PostgresLedger struct {
+	db *sql.DB
+}
+
+// NewPostgresLedger wraps db. Call EnsureSchema once before using it.
+func NewPostgresLedger(db *sql.DB) *PostgresLedger {
+	return &PostgresLedger{db: db}
+}
+
+// EnsureSchema creates the payouts table if it doesn't already exist.
+func (l *PostgresLedger) EnsureSchema() error {
+	_, err := l.db.Exec(`
+	CREATE TABLE IF NOT EXISTS payouts (
+		id SERIAL PRIMARY KEY,
+		worker_id VARCHAR(255) NOT NULL,
+		amount_wei NUMERIC(78, 0) NOT NULL,
+		tx_hash VARCHAR(255) NOT NULL,
+		paid_at TIMESTAMP NOT NULL DEFAULT NOW()
+	);`)
+	if err != nil {
+		return fmt.Errorf("payout: create payouts table: %w", err)
+	}
+	return nil
+}
+
+// RecordPayout inserts one completed payout row.
+func (l *PostgresLedger) RecordPayout(workerID string, amount *big.Int, txHash string) error {
+	_, err := l.db.Exec(
+		`INSERT INTO payouts (worker_id, amount_wei, tx_hash) VALUES ($1, $2, $3)`,
+		workerID, amount.String(), txHash,
+	)
+	if err != nil {
+		return fmt.Errorf("payout: record payout: %w", err)
+	}
+	return nil
+}
+
+// PendingBalances sums every worker's recorded payouts and returns that
+// sum keyed by worker ID. This ledger has no separate "pending" state
+// of its own — balances owed but not yet flushed still live in
+// Manager's in-memory map — so what's queried here is paid-to-date
+// totals, useful as an audit trail for /payouts/pending callers that
+// want to cross-check the in-memory figure.
+func (l *PostgresLedger) PendingBalances() (map[string]*big.Int, error) {
+	rows, err := l.db.Query(`SELECT worker_id, SUM(amount_wei) FROM payouts GROUP BY worker_id`)
+	if err != nil {
+		return nil, fmt.Errorf("payout: query pending balances: %w", err)
+	}
+	defer rows.Close()
+
+	out := make(map[string]*big.Int)
+	for rows.Next() {
+		var workerID, sum string
+		if err := rows.Scan(&workerID, &sum); err != nil {
+			return nil, fmt.Errorf("payout: scan pending balance row: %w", err)
+		}
+		amount, ok := new(big.Int).SetString(sum, 10)
+		if !ok {
+			return nil, fmt.Errorf("payout: malformed amount %q for worker %s", sum, workerID)
+		}
+		out[workerID] = amount
+	}
+	return out, rows.Err()
+}
+
+// History returns the most recent payouts, newest first, capped at limit.
+func (l *PostgresLedger) History(limit int) ([]PayoutRecord, error) {
+	if limit <= 0 {
+		limit = 100
+	}
+	rows, err := l.db.Query(
+		`SELECT worker_id, amount_wei, tx_hash, paid_at FROM payouts ORDER BY paid_at DESC LIMIT $1`,
+		limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("payout: query payout history: %w", err)
+	}
+	defer rows.Close()
+
+	var out []PayoutRecord
+	for rows.Next() {
+		var rec PayoutRecord
+		var amount string
+		var paidAt time.Time
+		if err := rows.Scan(&rec.WorkerID, &amount, &rec.TxHash, &paidAt); err != nil {
+			return nil, fmt.Errorf("payout: scan payout history row: %w", err)
+		}
+		parsed, ok := new(big.Int).SetString(amount, 10)
+		if !ok {
+			return nil, fmt.Errorf("payout: malformed amount %q for worker %s", amount, rec.WorkerID)
+		}
+		rec.Amount = parsed
+		rec.PaidAt = paidAt
+		out = append(out, rec)
+	}
+	return out, rows.Err()
+}
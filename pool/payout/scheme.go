@@ -0,0 +1,293 @@
+// Package payout implements pluggable payout schemes (PPLNS, PPS, FPPS,
+// SOLO) on top of the shares accepted by core.ShareProcessor. Each
+// scheme tracks per-worker balances in memory against a configurable
+// pool fee and flushes a payout once a worker crosses its threshold.
+package payout
+
+import (
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	"pool/core"
+	"pool/logs"
+	"pool/metrics"
+)
+
+// Scheme computes worker balances from accepted shares.
+type Scheme interface {
+	Name() string
+	// RecordShare folds a newly accepted share into the scheme's state.
+	RecordShare(share core.Share)
+	// OnBlockFound distributes blockReward (in wei) across the workers
+	// that contributed to it, returning the gross credit per worker
+	// before the pool fee is applied.
+	OnBlockFound(blockReward *big.Int) map[string]*big.Int
+}
+
+// Ledger persists payouts and pending balances. Implemented by
+// PostgresLedger.
+type Ledger interface {
+	RecordPayout(workerID string, amount *big.Int, txHash string) error
+	PendingBalances() (map[string]*big.Int, error)
+	// History returns the most recent payouts, newest first, capped at
+	// limit.
+	History(limit int) ([]PayoutRecord, error)
+}
+
+// PayoutRecord is one row of payout history, as returned by Ledger.History
+// and served by the /payouts/history endpoint.
+type PayoutRecord struct {
+	WorkerID string
+	Amount   *big.Int
+	TxHash   string
+	PaidAt   time.Time
+}
+
+// RewardSender mirrors the interface the pool already uses in
+// http.SetPaymentClient, so the manager can invoke payouts without
+// depending on the smartcontract package directly.
+type RewardSender interface {
+	SendReward(to string, amount *big.Int) (string, error)
+}
+
+// Config selects the scheme and the thresholds governing payouts.
+type Config struct {
+	SchemeName  string  // "pplns", "pps", "fpps", "solo"
+	PoolFee     float64 // fraction taken by the pool, e.g. 0.02 for 2%
+	Threshold   *big.Int
+	PPLNSWindow int // number of trailing shares considered, PPLNS only
+}
+
+// Manager wires a Scheme to the ledger and the payment engine, crediting
+// workers on each accepted share and flushing payouts once a worker's
+// pending balance clears the configured threshold.
+type Manager struct {
+	cfgMu sync.RWMutex
+	cfg   Config
+
+	scheme  Scheme
+	ledger  Ledger
+	sender  RewardSender
+	addrsMu sync.RWMutex
+	addrs   map[string]string // workerID -> payout address
+
+	balMu    sync.Mutex
+	balances map[string]*big.Int
+}
+
+// NewManager builds the scheme selected by cfg.SchemeName.
+func NewManager(cfg Config, ledger Ledger, sender RewardSender) (*Manager, error) {
+	if cfg.PoolFee < 0 || cfg.PoolFee > 1 {
+		return nil, fmt.Errorf("payout: invalid pool fee %.4f", cfg.PoolFee)
+	}
+	if cfg.Threshold == nil {
+		cfg.Threshold = big.NewInt(0)
+	}
+
+	var scheme Scheme
+	switch cfg.SchemeName {
+	case "pplns":
+		window := cfg.PPLNSWindow
+		if window <= 0 {
+			window = 10000
+		}
+		scheme = newPPLNS(window)
+	case "pps":
+		scheme = newPPS()
+	case "fpps":
+		scheme = newFPPS()
+	case "solo":
+		scheme = newSolo()
+	default:
+		return nil, fmt.Errorf("payout: unknown scheme %q", cfg.SchemeName)
+	}
+
+	return &Manager{
+		cfg:      cfg,
+		scheme:   scheme,
+		ledger:   ledger,
+		sender:   sender,
+		addrs:    make(map[string]string),
+		balances: make(map[string]*big.Int),
+	}, nil
+}
+
+// RegisterAddress associates a worker ID with the address payouts should
+// be sent to. Without a registered address a worker's balance accrues
+// but SendReward is never invoked for it.
+func (m *Manager) RegisterAddress(workerID, address string) {
+	m.addrsMu.Lock()
+	defer m.addrsMu.Unlock()
+	m.addrs[workerID] = address
+}
+
+// OnAccepted is meant to be wired as core.ShareProcessor's OnAccepted
+// hook so every accepted share flows into the active scheme.
+func (m *Manager) OnAccepted(share core.Share) {
+	m.scheme.RecordShare(share)
+}
+
+// SetFeeAndThreshold updates the pool fee and payout threshold in place,
+// so a config reload can rotate them without rebuilding the scheme (and
+// losing its in-memory share history).
+func (m *Manager) SetFeeAndThreshold(fee float64, threshold *big.Int) error {
+	if fee < 0 || fee > 1 {
+		return fmt.Errorf("payout: invalid pool fee %.4f", fee)
+	}
+	if threshold == nil {
+		threshold = big.NewInt(0)
+	}
+
+	m.cfgMu.Lock()
+	defer m.cfgMu.Unlock()
+	m.cfg.PoolFee = fee
+	m.cfg.Threshold = threshold
+	return nil
+}
+
+// CreditBlock distributes blockReward according to the active scheme,
+// applies the pool fee, and credits each worker's pending balance.
+func (m *Manager) CreditBlock(blockReward *big.Int) {
+	roundStart := time.Now()
+	gross := m.scheme.OnBlockFound(blockReward)
+
+	m.cfgMu.RLock()
+	fee := m.cfg.PoolFee
+	m.cfgMu.RUnlock()
+
+	feeTotal := new(big.Int)
+	m.balMu.Lock()
+	for workerID, amount := range gross {
+		net := applyFee(amount, fee)
+		feeTotal.Add(feeTotal, new(big.Int).Sub(amount, net))
+		if existing, ok := m.balances[workerID]; ok {
+			m.balances[workerID] = new(big.Int).Add(existing, net)
+		} else {
+			m.balances[workerID] = net
+		}
+	}
+	m.balMu.Unlock()
+
+	if metrics.PayoutRoundDuration != nil {
+		metrics.PayoutRoundDuration.WithLabelValues(m.scheme.Name()).Observe(time.Since(roundStart).Seconds())
+	}
+	if metrics.PayoutFeeRevenue != nil && feeTotal.Sign() > 0 {
+		feeFloat, _ := new(big.Float).SetInt(feeTotal).Float64()
+		metrics.PayoutFeeRevenue.WithLabelValues(m.scheme.Name()).Add(feeFloat)
+	}
+
+	logs.WithFields(map[string]interface{}{
+		"scheme": m.scheme.Name(),
+		"miners": len(gross),
+	}).Info("Block reward distributed across workers")
+
+	m.flushDue()
+}
+
+// MarkOrphaned records that a block this manager was crediting toward
+// got orphaned by a reorg before it could be confirmed. Callers own the
+// decision of whether an orphaned block's credits should be reversed;
+// this only updates the orphan-rate metric.
+func (m *Manager) MarkOrphaned() {
+	if metrics.PayoutOrphanedRounds != nil {
+		metrics.PayoutOrphanedRounds.WithLabelValues(m.scheme.Name()).Inc()
+	}
+}
+
+// Pending returns a snapshot of every worker's pending balance. It
+// prefers the ledger (the durable source of truth) when one is
+// configured, falling back to the in-memory balances tracked since this
+// Manager started.
+func (m *Manager) Pending() (map[string]*big.Int, error) {
+	if m.ledger != nil {
+		return m.ledger.PendingBalances()
+	}
+
+	m.balMu.Lock()
+	defer m.balMu.Unlock()
+	out := make(map[string]*big.Int, len(m.balances))
+	for workerID, balance := range m.balances {
+		out[workerID] = new(big.Int).Set(balance)
+	}
+	return out, nil
+}
+
+// History returns the most recent payouts sent by this manager, newest
+// first. It requires a ledger; without one there's nowhere payouts were
+// durably recorded to look them up.
+func (m *Manager) History(limit int) ([]PayoutRecord, error) {
+	if m.ledger == nil {
+		return nil, fmt.Errorf("payout: no ledger configured, payout history is unavailable")
+	}
+	return m.ledger.History(limit)
+}
+
+func (m *Manager) flushDue() {
+	m.cfgMu.RLock()
+	threshold := m.cfg.Threshold
+	m.cfgMu.RUnlock()
+
+	// Snapshot the balances due for payout under m.balMu rather than
+	// holding it for the whole loop: SendReward does network I/O, and
+	// Pending/CreditBlock both take m.balMu to touch the same map, so
+	// holding it across a send would block them for however long the
+	// send takes.
+	m.balMu.Lock()
+	due := make(map[string]*big.Int, len(m.balances))
+	for workerID, balance := range m.balances {
+		if balance.Cmp(threshold) < 0 {
+			continue
+		}
+		due[workerID] = new(big.Int).Set(balance)
+	}
+	m.balMu.Unlock()
+
+	for workerID, balance := range due {
+		m.addrsMu.RLock()
+		addr, ok := m.addrs[workerID]
+		m.addrsMu.RUnlock()
+		if !ok || m.sender == nil {
+			continue
+		}
+
+		txHash, err := m.sender.SendReward(addr, balance)
+		if err != nil {
+			logs.WithFields(map[string]interface{}{
+				"worker_id": workerID,
+				"error":     err.Error(),
+			}).Error("Payout send failed, balance retained")
+			continue
+		}
+
+		if m.ledger != nil {
+			if err := m.ledger.RecordPayout(workerID, balance, txHash); err != nil {
+				logs.WithFields(map[string]interface{}{
+					"worker_id": workerID,
+					"error":     err.Error(),
+				}).Error("Failed to record payout in ledger")
+			}
+		}
+
+		m.balMu.Lock()
+		m.balances[workerID] = big.NewInt(0)
+		m.balMu.Unlock()
+		logs.WithFields(map[string]interface{}{
+			"worker_id": workerID,
+			"amount":    balance.String(),
+			"tx_hash":   txHash,
+			"at":        time.Now().Format(time.RFC3339),
+		}).Info("Payout sent")
+	}
+}
+
+func applyFee(amount *big.Int, fee float64) *big.Int {
+	if fee <= 0 {
+		return new(big.Int).Set(amount)
+	}
+	feeBasisPoints := int64(fee * 10000)
+	fees := new(big.Int).Mul(amount, big.NewInt(feeBasisPoints))
+	fees.Div(fees, big.NewInt(10000))
+	return new(big.Int).Sub(amount, fees)
+}
@@ -0,0 +1,197 @@
+package payout
+
+import (
+	"math/big"
+	"sync"
+
+	"pool/core"
+)
+
+// weightedShare is the only bit of a core.Share the schemes need to
+// keep around once it has passed validation.
+type weightedShare struct {
+	workerID string
+	weight   float64
+}
+
+// --- PPLNS -----------------------------------------------------------
+
+// pplns credits workers proportionally to their share of the last N
+// shares in the pool-wide window, regardless of when the block was found.
+type pplns struct {
+	mu     sync.Mutex
+	window int
+	shares []weightedShare
+}
+
+func newPPLNS(window int) *pplns {
+	return &pplns{window: window}
+}
+
+func (p *pplns) Name() string { return "PPLNS" }
+
+func (p *pplns) RecordShare(share core.Share) {
+	w := share.Diff
+	if w <= 0 {
+		w = 1
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.shares = append(p.shares, weightedShare{workerID: share.WorkerID, weight: w})
+	if over := len(p.shares) - p.window; over > 0 {
+		p.shares = p.shares[over:]
+	}
+}
+
+func (p *pplns) OnBlockFound(blockReward *big.Int) map[string]*big.Int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	totals := make(map[string]float64)
+	var sum float64
+	for _, s := range p.shares {
+		totals[s.workerID] += s.weight
+		sum += s.weight
+	}
+
+	out := make(map[string]*big.Int, len(totals))
+	if sum == 0 {
+		return out
+	}
+	for workerID, weight := range totals {
+		out[workerID] = proportional(blockReward, weight, sum)
+	}
+	return out
+}
+
+// --- PPS ---------------------------------------------------------------
+
+// pps credits a fixed reward per share as each share is recorded,
+// independent of blockReward; the accumulated credit is handed out on
+// the next OnBlockFound call. RatePerShare is expressed in wei so the
+// accumulator stays exact.
+type pps struct {
+	mu           sync.Mutex
+	ratePerShare *big.Int
+	pending      map[string]*big.Int
+}
+
+func newPPS() *pps {
+	return &pps{
+		ratePerShare: big.NewInt(1),
+		pending:      make(map[string]*big.Int),
+	}
+}
+
+func (p *pps) Name() string { return "PPS" }
+
+func (p *pps) RecordShare(share core.Share) {
+	weight := int64(share.Diff)
+	if weight <= 0 {
+		weight = 1
+	}
+	credit := new(big.Int).Mul(p.ratePerShare, big.NewInt(weight))
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if existing, ok := p.pending[share.WorkerID]; ok {
+		p.pending[share.WorkerID] = new(big.Int).Add(existing, credit)
+	} else {
+		p.pending[share.WorkerID] = credit
+	}
+}
+
+// OnBlockFound for PPS ignores blockReward — each share's credit was
+// fixed when it was recorded — and simply drains the accumulator built
+// since the last call.
+func (p *pps) OnBlockFound(_ *big.Int) map[string]*big.Int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	out := p.pending
+	p.pending = make(map[string]*big.Int)
+	return out
+}
+
+// --- FPPS ----------------------------------------------------------------
+
+// fpps is PPS plus an averaged transaction fee share, tracked as an
+// extra flat bonus per share credited alongside the base PPS rate.
+type fpps struct {
+	*pps
+	feeBonusPerShare *big.Int
+}
+
+func newFPPS() *fpps {
+	return &fpps{
+		pps:              newPPS(),
+		feeBonusPerShare: big.NewInt(0),
+	}
+}
+
+func (f *fpps) Name() string { return "FPPS" }
+
+func (f *fpps) RecordShare(share core.Share) {
+	f.pps.RecordShare(share)
+	if f.feeBonusPerShare.Sign() == 0 {
+		return
+	}
+	weight := int64(share.Diff)
+	if weight <= 0 {
+		weight = 1
+	}
+	bonus := new(big.Int).Mul(f.feeBonusPerShare, big.NewInt(weight))
+
+	f.pps.mu.Lock()
+	defer f.pps.mu.Unlock()
+	if existing, ok := f.pps.pending[share.WorkerID]; ok {
+		f.pps.pending[share.WorkerID] = new(big.Int).Add(existing, bonus)
+	} else {
+		f.pps.pending[share.WorkerID] = bonus
+	}
+}
+
+// SetFeeBonus updates the per-share average-fee bonus, typically
+// recomputed on an interval from recent block transaction fees.
+func (f *fpps) SetFeeBonus(bonus *big.Int) {
+	f.feeBonusPerShare = bonus
+}
+
+// --- SOLO ------------------------------------------------------------
+
+// solo pays the entire block reward to the worker of the most recently
+// recorded share, taken as the one that found the block; there is no
+// cross-worker sharing.
+type solo struct {
+	mu     sync.Mutex
+	winner string
+}
+
+func newSolo() *solo {
+	return &solo{}
+}
+
+func (s *solo) Name() string { return "SOLO" }
+
+func (s *solo) RecordShare(share core.Share) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.winner = share.WorkerID
+}
+
+func (s *solo) OnBlockFound(blockReward *big.Int) map[string]*big.Int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.winner == "" {
+		return map[string]*big.Int{}
+	}
+	return map[string]*big.Int{s.winner: new(big.Int).Set(blockReward)}
+}
+
+func proportional(total *big.Int, weight, sum float64) *big.Int {
+	if sum == 0 {
+		return big.NewInt(0)
+	}
+	scaled := new(big.Float).Mul(new(big.Float).SetInt(total), big.NewFloat(weight/sum))
+	result, _ := scaled.Int(nil)
+	return result
+}
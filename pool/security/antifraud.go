@@ -1,21 +1,156 @@
 package security
 
 import (
+	"context"
+	"encoding"
+	"fmt"
 	"net"
+	"os"
+	"sort"
 	"strings"
 	"sync"
 	"time"
+
+	"pool/logs"
+	"pool/metrics"
+	"pool/security/antifraud"
 )
 
 const (
-	_windowSpan           = 15 * time.Second
-	_maxRequestsPerSubnet = 20
-	_nonceReuseWarn       = 3
-	_nonceReuseBlock      = 10
-	_greylistTimeout      = 3 * time.Minute
-	_clockSkewTolerance   = 2 * time.Minute
+	_windowSpan         = 15 * time.Second
+	_greylistTimeout    = 3 * time.Minute
+	_clockSkewTolerance = 2 * time.Minute
 )
 
+// InspectorConfig tunes the adaptive thresholds an Inspector uses for
+// subnet rate-limiting and hash/nonce reuse, in place of fixed
+// constants. Each WindowSpan tick, the Inspector samples the current
+// per-subnet and per-token counts into a ring buffer of the last
+// RingSize windows, then sets its live warn/block thresholds to the
+// WarnPct/BlockPct percentile of everything in the ring — similar in
+// spirit to a gas-price oracle sampling recent blocks. Floor and
+// Ceiling bound the result so a quiet pool (cold start, or a lull in
+// traffic) can't collapse the thresholds down to triggering on
+// essentially any request.
+type InspectorConfig struct {
+	WindowSpan time.Duration
+	RingSize   int
+	WarnPct    float64
+	BlockPct   float64
+	Floor      int
+	Ceiling    int
+}
+
+// DefaultInspectorConfig returns the settings LaunchInspector uses.
+// Floor/Ceiling are chosen so an Inspector with an empty ring (nothing
+// sampled yet) behaves exactly like this package's old hardcoded
+// constants did.
+func DefaultInspectorConfig() InspectorConfig {
+	return InspectorConfig{
+		WindowSpan: _windowSpan,
+		RingSize:   20,
+		WarnPct:    60,
+		BlockPct:   95,
+		Floor:      3,
+		Ceiling:    20,
+	}
+}
+
+// adaptiveThreshold maintains a rolling window of observation counts and
+// derives live warn/block thresholds from their percentiles.
+type adaptiveThreshold struct {
+	cfg InspectorConfig
+
+	mu     sync.Mutex
+	ring   [][]int
+	pos    int
+	filled bool
+	warn   int
+	block  int
+}
+
+func newAdaptiveThreshold(cfg InspectorConfig) *adaptiveThreshold {
+	return &adaptiveThreshold{
+		cfg:   cfg,
+		ring:  make([][]int, cfg.RingSize),
+		warn:  cfg.Floor,
+		block: cfg.Ceiling,
+	}
+}
+
+// sample records one window's worth of per-key observation counts and
+// recomputes the live thresholds from everything currently in the ring.
+func (a *adaptiveThreshold) sample(counts []int) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.ring[a.pos] = counts
+	a.pos++
+	if a.pos == len(a.ring) {
+		a.pos = 0
+		a.filled = true
+	}
+
+	n := a.pos
+	if a.filled {
+		n = len(a.ring)
+	}
+
+	var all []int
+	for i := 0; i < n; i++ {
+		all = append(all, a.ring[i]...)
+	}
+	if len(all) == 0 {
+		return
+	}
+
+	sorted := append([]int(nil), all...)
+	sort.Ints(sorted)
+
+	a.warn = clampInt(percentile(sorted, a.cfg.WarnPct), a.cfg.Floor, a.cfg.Ceiling)
+	a.block = clampInt(percentile(sorted, a.cfg.BlockPct), a.cfg.Floor, a.cfg.Ceiling)
+}
+
+func (a *adaptiveThreshold) thresholds() (warn, block int) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.warn, a.block
+}
+
+// percentile returns the pctile-th percentile (0-100) of an
+// already-sorted slice via nearest-rank interpolation.
+func percentile(sorted []int, pctile float64) int {
+	if len(sorted) == 0 {
+		return 0
+	}
+	idx := int(pctile / 100 * float64(len(sorted)-1))
+	if idx < 0 {
+		idx = 0
+	}
+	if idx >= len(sorted) {
+		idx = len(sorted) - 1
+	}
+	return sorted[idx]
+}
+
+func clampInt(v, floor, ceiling int) int {
+	if v < floor {
+		return floor
+	}
+	if v > ceiling {
+		return ceiling
+	}
+	return v
+}
+
+// InspectorStats reports the Inspector's current live thresholds, for
+// operators to inspect instead of having to guess at tuning constants.
+type InspectorStats struct {
+	SubnetBlockThreshold int
+	TokenWarnThreshold   int
+	TokenBlockThreshold  int
+}
+
 type ThreatLevel int
 
 const (
@@ -30,24 +165,67 @@ type Verdict struct {
 	Level   ThreatLevel
 }
 
-type record struct {
-	Timestamps []time.Time
+// ctxMutex is a channel-based binary semaphore whose Lock honors context
+// cancellation, unlike sync.Mutex — a caller with a tight deadline gets
+// back ctx.Err() instead of blocking indefinitely behind a slow holder.
+type ctxMutex chan struct{}
+
+func newCtxMutex() ctxMutex {
+	return make(ctxMutex, 1)
 }
 
-type usage struct {
-	Entries []time.Time
+// Lock blocks until the semaphore is acquired or ctx is done, whichever
+// comes first.
+func (m ctxMutex) Lock(ctx context.Context) error {
+	select {
+	case m <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
 }
 
-type state struct {
-	Greylist map[string]time.Time
-	Subnets  map[string]record
-	Tokens   map[string]usage
-	NonceMap map[string]map[string]time.Time
+func (m ctxMutex) Unlock() {
+	<-m
 }
 
+// Inspector evaluates shares for fraud/abuse signals. Its request-rate
+// counters, hash/nonce-reuse tracking, and greylist all live behind an
+// InspectorStore rather than a local map, so multiple pool instances
+// backed by the same store (NewRedisStore) share one view of abusive
+// subnets and miners instead of each evaluating shares against its own
+// private state.
 type Inspector struct {
-	lock    sync.Mutex
-	current state
+	cfg   InspectorConfig
+	id    string
+	store InspectorStore
+
+	// lock guards greylistCache and the observation buffers below, all
+	// of which are purely local bookkeeping — the store is the
+	// authoritative source of truth for everything else. It's a
+	// ctxMutex rather than a sync.Mutex so a caller with a deadline
+	// (LogRequest, through recordObservations and greylist) can give up
+	// on lock acquisition instead of hanging past its own SLO.
+	lock ctxMutex
+
+	// greylistCache tracks the greylist leases this instance currently
+	// believes it holds (subnet -> lease expiry), so refreshLeases knows
+	// what to renew. An entry is dropped the moment a refresh fails, so
+	// this instance's local state can't outlive what the store actually
+	// has recorded.
+	greylistCache map[string]time.Time
+
+	subnetObservations []int
+	tokenObservations  []int
+
+	subnetThresholds *adaptiveThreshold
+	tokenThresholds  *adaptiveThreshold
+
+	// flood runs the count-min-sketch flood check, duplicate-nonce ratio
+	// check, and adaptive ban policy described in package
+	// pool/security/antifraud, on top of this Inspector's own
+	// rate-limit/nonce/hash checks.
+	flood *antifraud.Detector
 }
 
 var (
@@ -55,40 +233,227 @@ var (
 	once            sync.Once
 )
 
+// newInspector builds a ready-to-use, single-process Inspector backed by
+// an in-memory store and DefaultInspectorConfig's adaptive thresholds.
+// LaunchInspector wraps this for the process-wide singleton; tests that
+// need an isolated Inspector (so one test's state can't bleed into
+// another's) call it directly.
+func newInspector() *Inspector {
+	return newInspectorWithConfig(DefaultInspectorConfig(), NewMemoryStore())
+}
+
+// newInspectorWithConfig builds an Inspector against the given store,
+// with subnet and token thresholds adapting per cfg. The token oracle is
+// given half of cfg's Ceiling: hash/nonce reuse operates on a much
+// smaller scale than subnet request volume, and halving preserves this
+// package's old hardcoded defaults (warn at 3, block at 10) at cold
+// start, before either oracle has sampled anything.
+func newInspectorWithConfig(cfg InspectorConfig, store InspectorStore) *Inspector {
+	tokenCfg := cfg
+	tokenCfg.Ceiling = cfg.Ceiling / 2
+
+	return &Inspector{
+		cfg:              cfg,
+		id:               fmt.Sprintf("inspector-%d", time.Now().UnixNano()),
+		store:            store,
+		lock:             newCtxMutex(),
+		greylistCache:    make(map[string]time.Time),
+		subnetThresholds: newAdaptiveThreshold(cfg),
+		tokenThresholds:  newAdaptiveThreshold(tokenCfg),
+		flood:            antifraud.NewDetector(antifraud.DefaultConfig()),
+	}
+}
+
+// NewInspector builds an Inspector against a caller-supplied store (e.g.
+// NewRedisStore, for a multi-instance deployment) instead of the
+// process-wide in-memory singleton LaunchInspector manages.
+func NewInspector(cfg InspectorConfig, store InspectorStore) *Inspector {
+	insp := newInspectorWithConfig(cfg, store)
+	go insp.refreshLeases()
+	go insp.sampleThresholds()
+	return insp
+}
+
+// inspectorSnapshotPath is where LaunchInspector's process-wide Inspector
+// persists its state between restarts.
+const inspectorSnapshotPath = "./data/inspector.snapshot"
+
 func LaunchInspector() *Inspector {
 	once.Do(func() {
-		globalInspector = &Inspector{
-			current: state{
-				Greylist: make(map[string]time.Time),
-				Subnets:  make(map[string]record),
-				Tokens:   make(map[string]usage),
-				NonceMap: make(map[string]map[string]time.Time),
-			},
+		globalInspector = newInspector()
+		if err := globalInspector.LoadSnapshot(inspectorSnapshotPath); err != nil {
+			logs.Warnf("Failed to load inspector snapshot: %v", err)
 		}
-		go globalInspector.cleanGreylist()
+		globalInspector.SnapshotEvery(_windowSpan, inspectorSnapshotPath)
+		go globalInspector.refreshLeases()
+		go globalInspector.sampleThresholds()
 	})
 	return globalInspector
 }
 
-func EvaluateShare(minerID, ip, nonce, hash string, ts time.Time) Verdict {
-	if globalInspector == nil {
-		LaunchInspector()
+// sampleThresholds feeds the adaptive oracles once per WindowSpan with
+// the counts observed over the window that just elapsed.
+func (i *Inspector) sampleThresholds() {
+	tick := time.NewTicker(i.cfg.WindowSpan)
+	defer tick.Stop()
+	for range tick.C {
+		// Background housekeeping has no caller deadline of its own, so
+		// it locks with Background rather than giving up.
+		_ = i.lock.Lock(context.Background())
+		subnetCounts := i.subnetObservations
+		tokenCounts := i.tokenObservations
+		i.subnetObservations = nil
+		i.tokenObservations = nil
+		i.lock.Unlock()
+
+		i.subnetThresholds.sample(subnetCounts)
+		i.tokenThresholds.sample(tokenCounts)
+	}
+}
+
+// recordObservations appends this call's subnet/token counts to the
+// buffers sampleThresholds drains once per window. It gives up on the
+// lock (without recording) if ctx is done first, so a caller racing its
+// own deadline never blocks on this purely-advisory bookkeeping.
+func (i *Inspector) recordObservations(ctx context.Context, subnetCount, tokenCount int) {
+	if err := i.lock.Lock(ctx); err != nil {
+		return
+	}
+	defer i.lock.Unlock()
+	i.subnetObservations = append(i.subnetObservations, subnetCount)
+	i.tokenObservations = append(i.tokenObservations, tokenCount)
+}
+
+// Bans lists every worker the flood/duplicate-nonce detector currently
+// has a ban record for, for the /security/bans endpoint.
+func (i *Inspector) Bans() []antifraud.BanInfo {
+	if i.flood == nil {
+		return nil
+	}
+	return i.flood.Bans()
+}
+
+// Unban lifts a worker's ban early, for the /security/bans/:id unban
+// endpoint. Reports false if the worker has no ban on record.
+func (i *Inspector) Unban(workerID string) bool {
+	if i.flood == nil {
+		return false
+	}
+	return i.flood.Unban(workerID)
+}
+
+// Stats reports the Inspector's current live thresholds.
+func (i *Inspector) Stats() InspectorStats {
+	_, subnetBlock := i.subnetThresholds.thresholds()
+	tokenWarn, tokenBlock := i.tokenThresholds.thresholds()
+	return InspectorStats{
+		SubnetBlockThreshold: subnetBlock,
+		TokenWarnThreshold:   tokenWarn,
+		TokenBlockThreshold:  tokenBlock,
 	}
+}
+
+// SnapshotEvery starts a background goroutine that writes i's store
+// state to path every interval d, so a restart doesn't wipe every
+// greylist entry, nonce-reuse window, and rate counter. Only stores that
+// implement encoding.BinaryMarshaler can be snapshotted this way —
+// currently just memoryStore, since a redisStore's state already lives
+// in Redis and persists independently of this process. Each write goes
+// to "${path}.tmp" first and is then renamed over path, so a crash
+// mid-write never leaves a half-written snapshot behind.
+func (i *Inspector) SnapshotEvery(d time.Duration, path string) {
+	marshaler, ok := i.store.(encoding.BinaryMarshaler)
+	if !ok {
+		logs.Debugf("Inspector snapshot skipped: store %T does not support snapshotting", i.store)
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(d)
+		defer ticker.Stop()
+		for range ticker.C {
+			data, err := marshaler.MarshalBinary()
+			if err != nil {
+				logs.Warnf("Inspector snapshot encode failed: %v", err)
+				continue
+			}
+			if err := writeAtomic(path, data); err != nil {
+				logs.Warnf("Inspector snapshot write failed: %v", err)
+			}
+		}
+	}()
+}
+
+// LoadSnapshot restores i's store state previously written by
+// SnapshotEvery from path. A missing file, or a store that doesn't
+// implement encoding.BinaryUnmarshaler, is not an error — there's
+// simply nothing to restore. A snapshot that fails to decode is treated
+// as corrupt: it's discarded and counted via metrics.SnapshotCorrupted
+// instead of panicking, since a corrupt snapshot just means starting
+// with an empty state. TTL-expired entries are dropped as part of the
+// decode itself (see memoryStore.UnmarshalBinary).
+func (i *Inspector) LoadSnapshot(path string) error {
+	unmarshaler, ok := i.store.(encoding.BinaryUnmarshaler)
+	if !ok {
+		return nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("security: read inspector snapshot: %w", err)
+	}
+
+	if err := unmarshaler.UnmarshalBinary(data); err != nil {
+		metrics.SnapshotCorrupted.WithLabelValues("inspector").Inc()
+		logs.Warnf("Inspector snapshot at %s is corrupt, starting empty: %v", path, err)
+		return nil
+	}
+	return nil
+}
 
+// writeAtomic writes data to "${path}.tmp" then renames it over path, so
+// readers never observe a partially written file.
+func writeAtomic(path string, data []byte) error {
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0o600); err != nil {
+		return fmt.Errorf("write tmp: %w", err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		return fmt.Errorf("rename into place: %w", err)
+	}
+	return nil
+}
+
+// Evaluate runs a share through the rate-limit, nonce-reuse, and
+// hash-reuse checks, respecting ctx's deadline throughout — a caller
+// enforcing a per-request SLO (e.g. the HTTP server's WithTimeout
+// middleware) can cancel ctx and Evaluate gives up on whatever check is
+// in flight instead of running it to completion. It is the instance
+// method backing the package-level EvaluateShare, so callers that run
+// their own Inspector (e.g. the Stratum front-end) can run the same
+// checks without going through the process-wide singleton.
+func (i *Inspector) Evaluate(ctx context.Context, minerID, ip, nonce, hash string, ts time.Time) Verdict {
 	now := time.Now()
 	if ts.IsZero() || ts.After(now.Add(_clockSkewTolerance)) || now.Sub(ts) > _clockSkewTolerance {
 		return Verdict{Flagged: true, Reason: "clock skew out of tolerance", Level: Warn}
 	}
 
 	subnet := extractSubnet(ip)
-	lvl := globalInspector.LogRequest(subnet, nonce)
+	lvl := i.LogRequest(ctx, subnet, nonce)
 	if lvl == Block {
 		return Verdict{Flagged: true, Reason: "rate limit subnet/24 exceeded (greylisted)", Level: Block}
 	}
-	if lvl == Warn {
+
+	if i.flood != nil {
+		if reason, blocked := i.flood.Record(minerID, subnet, nonce, ts, now); blocked {
+			return Verdict{Flagged: true, Reason: reason, Level: Block}
+		}
 	}
 
-	nlvl, nreason := globalInspector.checkNonceReuse(minerID, nonce, now)
+	nlvl, nreason := i.checkNonceReuse(ctx, minerID, nonce, now)
 	switch nlvl {
 	case Block:
 		return Verdict{Flagged: true, Reason: nreason, Level: Block}
@@ -97,7 +462,7 @@ func EvaluateShare(minerID, ip, nonce, hash string, ts time.Time) Verdict {
 	default:
 	}
 
-	hlvl, hreason := globalInspector.markToken(hash, now)
+	hlvl, hreason := i.markToken(ctx, hash, now)
 	if hlvl == Block {
 		return Verdict{Flagged: true, Reason: hreason, Level: Block}
 	}
@@ -105,123 +470,177 @@ func EvaluateShare(minerID, ip, nonce, hash string, ts time.Time) Verdict {
 		return Verdict{Flagged: true, Reason: hreason, Level: Warn}
 	}
 
-	if globalInspector.Check(subnet) {
+	if i.Check(subnet) {
 		return Verdict{Flagged: true, Reason: "subnet greylisted", Level: Block}
 	}
 
 	return Verdict{Flagged: false, Reason: "", Level: NoThreat}
 }
 
-func IsFraudulentNonce(minerID, nonce string) bool {
+// EvaluateShare runs a share through the process-wide Inspector returned
+// by LaunchInspector. See Inspector.Evaluate for the checks it runs.
+func EvaluateShare(ctx context.Context, minerID, ip, nonce, hash string, ts time.Time) Verdict {
+	if globalInspector == nil {
+		LaunchInspector()
+	}
+	return globalInspector.Evaluate(ctx, minerID, ip, nonce, hash, ts)
+}
+
+func IsFraudulentNonce(ctx context.Context, minerID, nonce string) bool {
 	if globalInspector == nil {
 		LaunchInspector()
 	}
-	verdict := EvaluateShare(minerID, minerID, nonce, "", time.Now())
+	verdict := EvaluateShare(ctx, minerID, minerID, nonce, "", time.Now())
 	return verdict.Level == Block
 }
 
-func (i *Inspector) cleanGreylist() {
-	tick := time.NewTicker(90 * time.Second)
+// refreshLeases periodically renews, via the store, every greylist
+// lease this instance currently holds. A failed refresh (the store's
+// copy already expired, or another instance's write raced it out) drops
+// the entry from greylistCache immediately, so this instance's local
+// view can never claim a subnet is greylisted-by-us longer than the
+// store agrees.
+func (i *Inspector) refreshLeases() {
+	tick := time.NewTicker(_greylistTimeout / 3)
 	defer tick.Stop()
 	for range tick.C {
-		now := time.Now()
-		i.lock.Lock()
-		for subnet, added := range i.current.Greylist {
-			if now.Sub(added) > _greylistTimeout {
-				delete(i.current.Greylist, subnet)
-			}
+		_ = i.lock.Lock(context.Background())
+		owned := make([]string, 0, len(i.greylistCache))
+		for subnet := range i.greylistCache {
+			owned = append(owned, subnet)
 		}
 		i.lock.Unlock()
+
+		for _, subnet := range owned {
+			i.refreshLease(subnet)
+		}
 	}
 }
 
-func (i *Inspector) Check(subnet string) bool {
-	i.lock.Lock()
+func (i *Inspector) refreshLease(subnet string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	newExpiry := time.Now().Add(_greylistTimeout)
+	err := i.store.ExpireAt(ctx, greylistKey(subnet), newExpiry)
+
+	_ = i.lock.Lock(context.Background())
 	defer i.lock.Unlock()
+	if err != nil {
+		delete(i.greylistCache, subnet)
+		return
+	}
+	i.greylistCache[subnet] = newExpiry
+}
 
-	added, exists := i.current.Greylist[subnet]
-	if !exists {
-		return false
+// greylist asks the store to greylist subnet and, if this instance wins
+// the lease, starts tracking it locally so refreshLeases renews it.
+func (i *Inspector) greylist(ctx context.Context, subnet string) {
+	ok, err := i.store.Greylist(ctx, subnet, i.id, _greylistTimeout)
+	if err != nil || !ok {
+		return
+	}
+	if lockErr := i.lock.Lock(ctx); lockErr != nil {
+		return
+	}
+	i.greylistCache[subnet] = time.Now().Add(_greylistTimeout)
+	i.lock.Unlock()
+}
+
+func (i *Inspector) Check(subnet string) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	greylisted, err := i.store.IsGreylisted(ctx, subnet)
+	if err != nil {
+		// A store error here must not silently wave every share
+		// through; the caller treats Check as a yes/no greylist gate,
+		// so fail toward the safer answer.
+		return true
 	}
-	return time.Since(added) <= _greylistTimeout
+	return greylisted
 }
 
-func (i *Inspector) LogRequest(ipOrSubnet string, token string) ThreatLevel {
+// LogRequest records one request against subnet and token rate counters
+// and returns the resulting threat level. ctx bounds both the store
+// round trips and the (purely advisory) observation bookkeeping, so a
+// caller enforcing its own deadline never blocks past it here.
+func (i *Inspector) LogRequest(ctx context.Context, ipOrSubnet string, token string) ThreatLevel {
 	subnet := extractSubnet(ipOrSubnet)
-	now := time.Now()
 
-	i.lock.Lock()
-	defer i.lock.Unlock()
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
 
-	rec := i.current.Subnets[subnet]
-	rec.Timestamps = pruneOld(rec.Timestamps, now)
-	rec.Timestamps = append(rec.Timestamps, now)
-	i.current.Subnets[subnet] = rec
+	subnetCount, err := i.store.Incr(ctx, subnetKey(subnet), _windowSpan)
+	if err != nil {
+		return NoThreat
+	}
+	tokenCount, err := i.store.Incr(ctx, tokenKey(token), _windowSpan)
+	if err != nil {
+		return NoThreat
+	}
+	i.recordObservations(ctx, int(subnetCount), int(tokenCount))
 
-	u := i.current.Tokens[token]
-	u.Entries = pruneOld(u.Entries, now)
-	u.Entries = append(u.Entries, now)
-	i.current.Tokens[token] = u
+	_, subnetBlock := i.subnetThresholds.thresholds()
+	tokenWarn, tokenBlock := i.tokenThresholds.thresholds()
 
-	if len(rec.Timestamps) > _maxRequestsPerSubnet {
-		i.current.Greylist[subnet] = now
+	if int(subnetCount) > subnetBlock {
+		i.greylist(ctx, subnet)
 		return Block
 	}
-	if len(u.Entries) >= _nonceReuseWarn && len(u.Entries) < _nonceReuseBlock {
+	if int(tokenCount) >= tokenWarn && int(tokenCount) < tokenBlock {
 		return Warn
 	}
-	if len(u.Entries) >= _nonceReuseBlock {
+	if int(tokenCount) >= tokenBlock {
 		return Block
 	}
 	return NoThreat
 }
 
-func (i *Inspector) checkNonceReuse(minerID, nonce string, now time.Time) (ThreatLevel, string) {
-	i.lock.Lock()
-	defer i.lock.Unlock()
-
-	if _, ok := i.current.NonceMap[minerID]; !ok {
-		i.current.NonceMap[minerID] = make(map[string]time.Time)
-	}
-
-	for n, t := range i.current.NonceMap[minerID] {
-		if now.Sub(t) > _windowSpan {
-			delete(i.current.NonceMap[minerID], n)
-		}
-	}
-
-	_, seen := i.current.NonceMap[minerID][nonce]
-	i.current.NonceMap[minerID][nonce] = now
+func (i *Inspector) checkNonceReuse(ctx context.Context, minerID, nonce string, now time.Time) (ThreatLevel, string) {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
 
-	if !seen {
+	seen, err := i.store.NonceSeen(ctx, minerID, nonce, now, _windowSpan)
+	if err != nil || !seen {
 		return NoThreat, ""
 	}
 
 	return Warn, "nonce reuse by miner within window"
 }
 
-func (i *Inspector) markToken(token string, now time.Time) (ThreatLevel, string) {
+func (i *Inspector) markToken(ctx context.Context, token string, now time.Time) (ThreatLevel, string) {
 	if token == "" {
 		return NoThreat, ""
 	}
 
-	i.lock.Lock()
-	defer i.lock.Unlock()
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	count, err := i.store.Incr(ctx, tokenKey(token), _windowSpan)
+	if err != nil {
+		return NoThreat, ""
+	}
 
-	u := i.current.Tokens[token]
-	u.Entries = pruneOld(u.Entries, now)
-	u.Entries = append(u.Entries, now)
-	i.current.Tokens[token] = u
+	tokenWarn, tokenBlock := i.tokenThresholds.thresholds()
 
-	if len(u.Entries) >= _nonceReuseBlock {
+	if int(count) >= tokenBlock {
 		return Block, "hash reuse over hard threshold"
 	}
-	if len(u.Entries) >= _nonceReuseWarn {
+	if int(count) >= tokenWarn {
 		return Warn, "hash reuse approaching threshold"
 	}
 	return NoThreat, ""
 }
 
+func subnetKey(subnet string) string {
+	return "inspector:subnet:" + subnet
+}
+
+func tokenKey(token string) string {
+	return "inspector:token:" + token
+}
+
 func extractSubnet(ipStr string) string {
 	ip := net.ParseIP(strings.TrimSpace(ipStr))
 	if ip == nil {
@@ -233,13 +652,3 @@ func extractSubnet(ipStr string) string {
 	}
 	return ip.String()
 }
-
-func pruneOld(ts []time.Time, now time.Time) []time.Time {
-	filtered := ts[:0]
-	for _, t := range ts {
-		if now.Sub(t) <= _windowSpan {
-			filtered = append(filtered, t)
-		}
-	}
-	return filtered
-}
@@ -0,0 +1,209 @@
+// Package antifraud implements the flood- and replay-detection layer
+// security.Inspector consults in addition to its own rate-limit and
+// nonce/hash-reuse checks: a count-min sketch tracks (worker, IP) share
+// volume cheaply enough to run on every submission without per-key
+// memory growth, a duplicate-nonce ratio catches miners replaying a
+// narrow set of nonces without tripping the exact-reuse check, and a
+// token-bucket-style ban policy quarantines repeat offenders for
+// exponentially increasing durations.
+package antifraud
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	sketchWidth = 2048
+	sketchDepth = 4
+)
+
+// Config tunes a Detector's thresholds. DefaultConfig's values are a
+// reasonable starting point for a small-to-medium pool; operators
+// running much higher submission rates should raise FloodThreshold
+// accordingly.
+type Config struct {
+	// FloodThreshold is the estimated per-(worker,IP) share count within
+	// the trailing RotateInterval*2 window above which Record reports a
+	// flood.
+	FloodThreshold uint32
+	// RotateInterval is how often the underlying sketch pair swaps, so
+	// FloodThreshold is evaluated against roughly the last
+	// 2*RotateInterval of traffic.
+	RotateInterval time.Duration
+	// DuplicateNonceRatio is the dup/total ratio, once at least
+	// MinNonceSamples nonces have been seen for a worker, above which
+	// Record reports duplicate-nonce abuse.
+	DuplicateNonceRatio float64
+	MinNonceSamples     int
+	// BanBase and BanMax bound the exponential ban policy: the first
+	// strike bans for BanBase, doubling each subsequent strike up to
+	// BanMax.
+	BanBase time.Duration
+	BanMax  time.Duration
+	// TimeTravelThreshold is how far ahead of the server's own clock a
+	// share's claimed timestamp can drift before Record treats it as a
+	// time-travel anomaly and strikes the ban policy, same as flood and
+	// duplicate-nonce abuse.
+	TimeTravelThreshold time.Duration
+}
+
+// DefaultConfig returns the thresholds LaunchInspector's Detector uses.
+func DefaultConfig() Config {
+	return Config{
+		FloodThreshold:      120, // ~2 shares/sec sustained across a 1-minute window
+		RotateInterval:      30 * time.Second,
+		DuplicateNonceRatio: 0.2,
+		MinNonceSamples:     20,
+		BanBase:             30 * time.Second,
+		BanMax:              1 * time.Hour,
+		TimeTravelThreshold: 2 * time.Second,
+	}
+}
+
+// nonceHistory tracks, per worker, how many of its nonces repeated one
+// of its last nonceHistorySize nonces. The ring is bounded so a long-lived worker's memory
+// footprint here never grows past nonceHistorySize entries.
+const nonceHistorySize = 128
+
+type nonceHistory struct {
+	mu     sync.Mutex
+	recent map[string][]string
+	total  map[string]int
+	dup    map[string]int
+}
+
+func newNonceHistory() *nonceHistory {
+	return &nonceHistory{
+		recent: make(map[string][]string),
+		total:  make(map[string]int),
+		dup:    make(map[string]int),
+	}
+}
+
+// record folds in workerID's latest nonce and returns its duplicate
+// ratio (repeats over every nonce recorded for the worker so far) plus
+// that total. A nonce counts as a repeat when it matches one in the ring.
+func (n *nonceHistory) record(workerID, nonce string) (ratio float64, total int) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	ring := n.recent[workerID]
+	isDup := false
+	for _, seen := range ring {
+		if seen == nonce {
+			isDup = true
+			break
+		}
+	}
+
+	n.total[workerID]++
+	if isDup {
+		n.dup[workerID]++
+	}
+
+	ring = append(ring, nonce)
+	if len(ring) > nonceHistorySize {
+		ring = ring[len(ring)-nonceHistorySize:]
+	}
+	n.recent[workerID] = ring
+
+	total = n.total[workerID]
+	if total == 0 {
+		return 0, 0
+	}
+	return float64(n.dup[workerID]) / float64(total), total
+}
+
+// Detector is the flood/replay/ban subsystem. The zero value isn't
+// ready to use; build one with NewDetector.
+type Detector struct {
+	cfg     Config
+	sketch  *rotatingSketch
+	nonces  *nonceHistory
+	bans    *banPolicy
+	closeCh chan struct{}
+}
+
+// NewDetector builds a Detector and starts its sketch-rotation
+// goroutine. Callers that want the background goroutine stopped (e.g.
+// tests constructing many short-lived Detectors) can call Close.
+func NewDetector(cfg Config) *Detector {
+	if cfg.RotateInterval <= 0 {
+		cfg.RotateInterval = 30 * time.Second
+	}
+	if cfg.TimeTravelThreshold <= 0 {
+		cfg.TimeTravelThreshold = 2 * time.Second
+	}
+	d := &Detector{
+		cfg:     cfg,
+		sketch:  newRotatingSketch(sketchWidth, sketchDepth),
+		nonces:  newNonceHistory(),
+		bans:    newBanPolicy(cfg.BanBase, cfg.BanMax),
+		closeCh: make(chan struct{}),
+	}
+	go d.rotateLoop()
+	return d
+}
+
+func (d *Detector) rotateLoop() {
+	ticker := time.NewTicker(d.cfg.RotateInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			d.sketch.rotate()
+		case <-d.closeCh:
+			return
+		}
+	}
+}
+
+// Close stops the background rotation goroutine.
+func (d *Detector) Close() {
+	close(d.closeCh)
+}
+
+// Record folds in one share observation for (workerID, ip, nonce),
+// submitted with the claimed timestamp shareTS, and reports whether it
+// should be blocked, along with the reason. An already-banned worker is
+// blocked outright without touching the sketch or nonce history. A
+// flood, duplicate-nonce, or time-travel verdict all register a new
+// strike against the worker via the ban policy.
+func (d *Detector) Record(workerID, ip, nonce string, shareTS, now time.Time) (reason string, blocked bool) {
+	if d.bans.isBanned(workerID, now) {
+		return "worker banned", true
+	}
+
+	if !shareTS.IsZero() && shareTS.Sub(now) > d.cfg.TimeTravelThreshold {
+		d.bans.ban(workerID, now)
+		return "time-travel anomaly: share timestamp ahead of server clock", true
+	}
+
+	key := workerID + "|" + ip
+	if count := d.sketch.add(key); count > d.cfg.FloodThreshold {
+		d.bans.ban(workerID, now)
+		return "flood threshold exceeded", true
+	}
+
+	if ratio, total := d.nonces.record(workerID, nonce); total >= d.cfg.MinNonceSamples && ratio > d.cfg.DuplicateNonceRatio {
+		d.bans.ban(workerID, now)
+		return "duplicate-nonce ratio exceeded", true
+	}
+
+	return "", false
+}
+
+// Bans lists every worker this Detector currently has a ban record for,
+// including ones whose ban has already expired (BannedUntil in the
+// past) — callers that only want active bans should filter on that.
+func (d *Detector) Bans() []BanInfo {
+	return d.bans.list()
+}
+
+// Unban lifts workerID's ban immediately. Its strike count is kept, so
+// a subsequent offense still escalates rather than restarting at
+// BanBase. Reports false if workerID has no ban on record.
+func (d *Detector) Unban(workerID string) bool {
+	return d.bans.unban(workerID)
+}
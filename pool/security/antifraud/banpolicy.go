@@ -0,0 +1,92 @@
+package antifraud
+
+import (
+	"sync"
+	"time"
+)
+
+// BanInfo describes one worker's current ban state, as returned by
+// Detector.Bans for the /security/bans endpoint.
+type BanInfo struct {
+	WorkerID    string    `json:"worker_id"`
+	Strikes     int       `json:"strikes"`
+	BannedUntil time.Time `json:"banned_until"`
+}
+
+// banEntry tracks one worker's strike count and current ban expiry.
+type banEntry struct {
+	strikes int
+	until   time.Time
+}
+
+// banPolicy is a token-bucket-style adaptive ban tracker: each Ban call
+// is a "strike" that quarantines the worker for base*2^(strikes-1),
+// capped at max, so a worker that keeps re-offending right after its
+// ban lifts gets banned for progressively longer instead of cycling
+// through the same fixed penalty forever.
+type banPolicy struct {
+	base, max time.Duration
+
+	mu      sync.Mutex
+	entries map[string]*banEntry
+}
+
+func newBanPolicy(base, max time.Duration) *banPolicy {
+	return &banPolicy{
+		base:    base,
+		max:     max,
+		entries: make(map[string]*banEntry),
+	}
+}
+
+// ban records a new strike against workerID and returns how long the
+// resulting ban lasts.
+func (b *banPolicy) ban(workerID string, now time.Time) time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	e, ok := b.entries[workerID]
+	if !ok {
+		e = &banEntry{}
+		b.entries[workerID] = e
+	}
+	e.strikes++
+
+	dur := b.base << uint(e.strikes-1)
+	if dur <= 0 || dur > b.max {
+		dur = b.max
+	}
+	e.until = now.Add(dur)
+	return dur
+}
+
+func (b *banPolicy) isBanned(workerID string, now time.Time) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	e, ok := b.entries[workerID]
+	return ok && now.Before(e.until)
+}
+
+// unban lifts workerID's ban immediately without resetting its strike
+// count, so a subsequent re-offense still escalates from where it left
+// off. Reports false if workerID has no ban on record.
+func (b *banPolicy) unban(workerID string) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	e, ok := b.entries[workerID]
+	if !ok {
+		return false
+	}
+	e.until = time.Time{}
+	return true
+}
+
+func (b *banPolicy) list() []BanInfo {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	out := make([]BanInfo, 0, len(b.entries))
+	for workerID, e := range b.entries {
+		out = append(out, BanInfo{WorkerID: workerID, Strikes: e.strikes, BannedUntil: e.until})
+	}
+	return out
+}
@@ -0,0 +1,110 @@
+package antifraud
+
+import (
+	"encoding/binary"
+	"math"
+	"sync"
+
+	"github.com/cespare/xxhash/v2"
+)
+
+// countMinSketch is a fixed-size probabilistic frequency counter: it
+// never grows past width*depth uint32 counters regardless of how many
+// distinct keys flow through it, at the cost of occasionally
+// overestimating a key's true count (never underestimating one).
+type countMinSketch struct {
+	width, depth int
+	rows         [][]uint32
+	seeds        []uint64
+}
+
+func newCountMinSketch(width, depth int) *countMinSketch {
+	seeds := make([]uint64, depth)
+	rows := make([][]uint32, depth)
+	for i := range seeds {
+		// Distinct, fixed seeds per row so the depth rows hash
+		// independently of each other instead of degenerating into the
+		// same bucket assignment.
+		seeds[i] = uint64(i+1) * 0x9E3779B97F4A7C15
+		rows[i] = make([]uint32, width)
+	}
+	return &countMinSketch{width: width, depth: depth, rows: rows, seeds: seeds}
+}
+
+func (s *countMinSketch) index(key string, row int) int {
+	var seedBuf [8]byte
+	binary.LittleEndian.PutUint64(seedBuf[:], s.seeds[row])
+	h := xxhash.New()
+	h.Write(seedBuf[:])
+	h.Write([]byte(key))
+	return int(h.Sum64() % uint64(s.width))
+}
+
+// add increments key's counter in every row and returns the new
+// min-count estimate.
+func (s *countMinSketch) add(key string) uint32 {
+	min := uint32(math.MaxUint32)
+	for row := 0; row < s.depth; row++ {
+		idx := s.index(key, row)
+		s.rows[row][idx]++
+		if s.rows[row][idx] < min {
+			min = s.rows[row][idx]
+		}
+	}
+	return min
+}
+
+// estimate returns key's current min-count estimate without mutating
+// the sketch.
+func (s *countMinSketch) estimate(key string) uint32 {
+	min := uint32(math.MaxUint32)
+	for row := 0; row < s.depth; row++ {
+		idx := s.index(key, row)
+		if s.rows[row][idx] < min {
+			min = s.rows[row][idx]
+		}
+	}
+	return min
+}
+
+// rotatingSketch approximates a trailing one-minute count using two
+// count-min sketches: a "current" one absorbing new observations and a
+// "previous" one holding the window before it, swapped every
+// rotateInterval (30s by default, so previous+current together cover
+// roughly the last minute). This avoids ever having to expire
+// individual keys out of a sketch, which count-min's structure doesn't
+// support.
+type rotatingSketch struct {
+	width, depth int
+
+	mu                sync.Mutex
+	current, previous *countMinSketch
+}
+
+func newRotatingSketch(width, depth int) *rotatingSketch {
+	return &rotatingSketch{
+		width:    width,
+		depth:    depth,
+		current:  newCountMinSketch(width, depth),
+		previous: newCountMinSketch(width, depth),
+	}
+}
+
+// add records one observation of key and returns the combined
+// current+previous estimate for it.
+func (r *rotatingSketch) add(key string) uint32 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	c := r.current.add(key)
+	p := r.previous.estimate(key)
+	return c + p
+}
+
+// rotate retires the current sketch into previous and starts a fresh
+// current sketch.
+func (r *rotatingSketch) rotate() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.previous = r.current
+	r.current = newCountMinSketch(r.width, r.depth)
+}
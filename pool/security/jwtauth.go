@@ -0,0 +1,252 @@
+package security
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"fmt"
+	"math/big"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Claims is the payload pool-issued JWTs carry: which miner the token
+// authenticates and which scopes it's allowed to use (e.g.
+// "share:submit", "payout:write").
+type Claims struct {
+	MinerID string   `json:"miner_id"`
+	Scopes  []string `json:"scopes"`
+	jwt.RegisteredClaims
+}
+
+// HasScope reports whether the token carries the given scope.
+func (c *Claims) HasScope(scope string) bool {
+	for _, s := range c.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// Verifier validates a bearer token string and returns its claims.
+// *JWTAuthenticator is the production implementation; tests can supply a
+// stub.
+type Verifier interface {
+	Verify(tokenString string) (*Claims, error)
+}
+
+// rsaKeyPair is one generation of the authenticator's signing key.
+// expiresAt is only set once the key is retired (replaced as the active
+// signing key) — tokens it already signed must keep validating for the
+// rest of their natural lifetime, not just until the next rotation.
+type rsaKeyPair struct {
+	kid       string
+	private   *rsa.PrivateKey
+	public    *rsa.PublicKey
+	expiresAt time.Time
+}
+
+func newRSAKeyPair() (*rsaKeyPair, error) {
+	priv, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, err
+	}
+	return &rsaKeyPair{
+		kid:     strconv.FormatInt(time.Now().UnixNano(), 36),
+		private: priv,
+		public:  &priv.PublicKey,
+	}, nil
+}
+
+// JWTAuthenticator issues and verifies pool auth tokens off a
+// self-managed, rotating RSA keypair. StartRotation generates a fresh
+// signing key on a timer; the previous key keeps verifying for
+// maxTokenLifetime after it's retired, so tokens already handed out to
+// miners don't suddenly stop validating mid-rotation.
+type JWTAuthenticator struct {
+	issuer           string
+	audience         string
+	tokenTTL         time.Duration
+	maxTokenLifetime time.Duration
+
+	mu      sync.RWMutex
+	active  *rsaKeyPair
+	retired map[string]*rsaKeyPair
+
+	stop chan struct{}
+}
+
+// NewJWTAuthenticator generates the initial signing key and returns a
+// ready-to-use authenticator. Call StartRotation to begin rotating keys
+// in the background; without it, the authenticator just never rotates.
+func NewJWTAuthenticator(issuer, audience string, tokenTTL, maxTokenLifetime time.Duration) (*JWTAuthenticator, error) {
+	key, err := newRSAKeyPair()
+	if err != nil {
+		return nil, fmt.Errorf("security: failed to generate initial signing key: %w", err)
+	}
+	return &JWTAuthenticator{
+		issuer:           issuer,
+		audience:         audience,
+		tokenTTL:         tokenTTL,
+		maxTokenLifetime: maxTokenLifetime,
+		active:           key,
+		retired:          make(map[string]*rsaKeyPair),
+		stop:             make(chan struct{}),
+	}, nil
+}
+
+// StartRotation generates a new signing key every interval, retiring the
+// previous one. Safe to call at most once per authenticator.
+func (a *JWTAuthenticator) StartRotation(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				a.rotate()
+			case <-a.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Stop halts the background rotation loop started by StartRotation. A
+// JWTAuthenticator that never called StartRotation does not need Stop.
+func (a *JWTAuthenticator) Stop() {
+	close(a.stop)
+}
+
+func (a *JWTAuthenticator) rotate() {
+	next, err := newRSAKeyPair()
+	if err != nil {
+		// Keep signing with the current key rather than take the
+		// authenticator down over a transient entropy/allocation error.
+		return
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	retiring := a.active
+	retiring.expiresAt = time.Now().Add(a.maxTokenLifetime)
+	a.retired[retiring.kid] = retiring
+	a.active = next
+
+	now := time.Now()
+	for kid, k := range a.retired {
+		if now.After(k.expiresAt) {
+			delete(a.retired, kid)
+		}
+	}
+}
+
+// Issue signs a new token for minerID with the given scopes using the
+// current active signing key.
+func (a *JWTAuthenticator) Issue(minerID string, scopes []string) (string, error) {
+	a.mu.RLock()
+	key := a.active
+	a.mu.RUnlock()
+
+	now := time.Now()
+	claims := Claims{
+		MinerID: minerID,
+		Scopes:  scopes,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    a.issuer,
+			Audience:  jwt.ClaimStrings{a.audience},
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(a.tokenTTL)),
+		},
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = key.kid
+	return token.SignedString(key.private)
+}
+
+// Verify implements Verifier: it checks the signature against whichever
+// of the active or retired-but-not-yet-expired keys matches the token's
+// kid, plus the standard exp/nbf/iss/aud claims.
+func (a *JWTAuthenticator) Verify(tokenString string) (*Claims, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodRSA); !ok {
+			return nil, fmt.Errorf("security: unexpected signing method %v", token.Header["alg"])
+		}
+		kid, _ := token.Header["kid"].(string)
+		return a.keyFor(kid)
+	}, jwt.WithIssuer(a.issuer), jwt.WithAudience(a.audience))
+	if err != nil {
+		return nil, fmt.Errorf("security: token verification failed: %w", err)
+	}
+
+	claims, ok := token.Claims.(*Claims)
+	if !ok || !token.Valid {
+		return nil, fmt.Errorf("security: invalid token")
+	}
+	return claims, nil
+}
+
+func (a *JWTAuthenticator) keyFor(kid string) (*rsa.PublicKey, error) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	if a.active.kid == kid {
+		return a.active.public, nil
+	}
+	if k, ok := a.retired[kid]; ok && time.Now().Before(k.expiresAt) {
+		return k.public, nil
+	}
+	return nil, fmt.Errorf("security: unknown signing key %q", kid)
+}
+
+// JWK is the RFC 7517 subset this package publishes: RSA public keys
+// used for signature verification.
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+// JWKSDocument is the response body served at /jwks.json.
+type JWKSDocument struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS returns the active signing key plus every retired key still
+// within its verification window, so a client refreshing its key cache
+// mid-rotation can still validate tokens signed by either.
+func (a *JWTAuthenticator) JWKS() JWKSDocument {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	keys := make([]JWK, 0, 1+len(a.retired))
+	keys = append(keys, rsaPublicJWK(a.active.kid, a.active.public))
+	now := time.Now()
+	for _, k := range a.retired {
+		if now.Before(k.expiresAt) {
+			keys = append(keys, rsaPublicJWK(k.kid, k.public))
+		}
+	}
+	return JWKSDocument{Keys: keys}
+}
+
+func rsaPublicJWK(kid string, pub *rsa.PublicKey) JWK {
+	return JWK{
+		Kty: "RSA",
+		Kid: kid,
+		Alg: "RS256",
+		Use: "sig",
+		N:   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+		E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+	}
+}
@@ -0,0 +1,442 @@
+package security
+
+import (
+	"bytes"
+	"context"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ErrLeaseLost is returned by InspectorStore.ExpireAt when the caller no
+// longer holds (or never held) the lease it's trying to refresh — the
+// entry has already expired or been taken over by another instance.
+// Callers must drop any locally cached copy of that state rather than
+// keep acting on it.
+var ErrLeaseLost = errors.New("security: lease no longer held")
+
+// InspectorStore is the storage backend an Inspector uses for its
+// request-rate counters, hash/nonce-reuse tracking, and greylist
+// entries. memoryStore keeps everything local to one process; a Redis
+// backing (NewRedisStore) lets multiple pool instances share one view,
+// so an attacker spraying shares across instances still gets caught by
+// the pool as a whole instead of resetting the counters by switching
+// targets.
+type InspectorStore interface {
+	// Get returns the current count stored at key, or 0 if key doesn't
+	// exist or has expired.
+	Get(ctx context.Context, key string) (int64, error)
+
+	// Incr increments the counter at key by 1 and returns the new
+	// value. If this call creates key, it's given ttl as its
+	// expiration — a fresh sliding window.
+	Incr(ctx context.Context, key string, ttl time.Duration) (int64, error)
+
+	// ExpireAt refreshes key's absolute expiration time. It returns
+	// ErrLeaseLost if key no longer exists, so a caller renewing a
+	// lease it believes it holds finds out the shared copy is gone
+	// instead of silently extending nothing.
+	ExpireAt(ctx context.Context, key string, at time.Time) error
+
+	// Greylist marks subnet as greylisted until ttl elapses, tagged
+	// with holder (the instance ID making the call). Returns false
+	// without error if subnet was already greylisted by a different
+	// holder — whoever greylisted it first wins, and only that holder
+	// should go on to refresh the lease.
+	Greylist(ctx context.Context, subnet, holder string, ttl time.Duration) (bool, error)
+
+	// IsGreylisted reports whether subnet is currently greylisted by
+	// any instance.
+	IsGreylisted(ctx context.Context, subnet string) (bool, error)
+
+	// NonceSeen records that minerID submitted nonce at now and reports
+	// whether that same (minerID, nonce) pair was already seen within
+	// the trailing window.
+	NonceSeen(ctx context.Context, minerID, nonce string, now time.Time, window time.Duration) (bool, error)
+}
+
+type memCounter struct {
+	count     int64
+	expiresAt time.Time
+}
+
+type memGreylistEntry struct {
+	holder    string
+	expiresAt time.Time
+}
+
+// memoryStore is the single-process InspectorStore: a plain mutex-guarded
+// map, equivalent to what Inspector kept inline before this became
+// pluggable. Good for local dev and single-instance deployments; running
+// more than one pool instance needs NewRedisStore instead, or each
+// instance re-evaluates shares against its own private counters.
+type memoryStore struct {
+	mu        sync.Mutex
+	counters  map[string]memCounter
+	greylist  map[string]memGreylistEntry
+	nonceSeen map[string]map[string]time.Time
+}
+
+// NewMemoryStore returns the default single-process InspectorStore.
+func NewMemoryStore() InspectorStore {
+	return &memoryStore{
+		counters:  make(map[string]memCounter),
+		greylist:  make(map[string]memGreylistEntry),
+		nonceSeen: make(map[string]map[string]time.Time),
+	}
+}
+
+func (s *memoryStore) Get(_ context.Context, key string) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, ok := s.counters[key]
+	if !ok || time.Now().After(c.expiresAt) {
+		return 0, nil
+	}
+	return c.count, nil
+}
+
+func (s *memoryStore) Incr(_ context.Context, key string, ttl time.Duration) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	c, ok := s.counters[key]
+	if !ok || now.After(c.expiresAt) {
+		c = memCounter{count: 0, expiresAt: now.Add(ttl)}
+	}
+	c.count++
+	s.counters[key] = c
+	return c.count, nil
+}
+
+func (s *memoryStore) ExpireAt(_ context.Context, key string, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Greylist leases live in s.greylist keyed by subnet, not in counters.
+	if prefix := greylistKey(""); strings.HasPrefix(key, prefix) {
+		subnet := strings.TrimPrefix(key, prefix)
+		g, ok := s.greylist[subnet]
+		if !ok || time.Now().After(g.expiresAt) {
+			return ErrLeaseLost
+		}
+		g.expiresAt = at
+		s.greylist[subnet] = g
+		return nil
+	}
+
+	c, ok := s.counters[key]
+	if !ok {
+		return ErrLeaseLost
+	}
+	c.expiresAt = at
+	s.counters[key] = c
+	return nil
+}
+
+func (s *memoryStore) Greylist(_ context.Context, subnet, holder string, ttl time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if existing, ok := s.greylist[subnet]; ok && now.Before(existing.expiresAt) {
+		return existing.holder == holder, nil
+	}
+	s.greylist[subnet] = memGreylistEntry{holder: holder, expiresAt: now.Add(ttl)}
+	return true, nil
+}
+
+func (s *memoryStore) IsGreylisted(_ context.Context, subnet string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.greylist[subnet]
+	if !ok {
+		return false, nil
+	}
+	if time.Now().After(entry.expiresAt) {
+		delete(s.greylist, subnet)
+		return false, nil
+	}
+	return true, nil
+}
+
+func (s *memoryStore) NonceSeen(_ context.Context, minerID, nonce string, now time.Time, window time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	byNonce, ok := s.nonceSeen[minerID]
+	if !ok {
+		byNonce = make(map[string]time.Time)
+		s.nonceSeen[minerID] = byNonce
+	}
+	for n, t := range byNonce {
+		if now.Sub(t) > window {
+			delete(byNonce, n)
+		}
+	}
+
+	_, seen := byNonce[nonce]
+	byNonce[nonce] = now
+	return seen, nil
+}
+
+// memoryStoreSnapshotVersion is bumped whenever memoryStoreDTO's fields
+// change in a way that isn't backward compatible, so loading a snapshot
+// can tell a stale layout apart from a corrupt one.
+const memoryStoreSnapshotVersion = 1
+
+// counterDTO/greylistDTO are gob-encodable copies of memCounter and
+// memGreylistEntry, which keep their own fields unexported since they're
+// purely store-internal; gob can't see unexported fields, so the
+// snapshot needs its own exported shape.
+type counterDTO struct {
+	Count     int64
+	ExpiresAt time.Time
+}
+
+type greylistDTO struct {
+	Holder    string
+	ExpiresAt time.Time
+}
+
+// memoryStoreDTO is memoryStore's on-disk shape: a flattened, directly
+// gob-encodable copy of its three maps.
+type memoryStoreDTO struct {
+	Version   int
+	Counters  map[string]counterDTO
+	Greylist  map[string]greylistDTO
+	NonceSeen map[string]map[string]time.Time
+}
+
+// MarshalBinary encodes the store's current state via gob. Only
+// memoryStore implements this — redisStore's state already lives in
+// Redis, which has its own persistence story, so there's nothing extra
+// for this package to snapshot there.
+func (s *memoryStore) MarshalBinary() ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	dto := memoryStoreDTO{
+		Version:   memoryStoreSnapshotVersion,
+		Counters:  make(map[string]counterDTO, len(s.counters)),
+		Greylist:  make(map[string]greylistDTO, len(s.greylist)),
+		NonceSeen: make(map[string]map[string]time.Time, len(s.nonceSeen)),
+	}
+	for k, c := range s.counters {
+		dto.Counters[k] = counterDTO{Count: c.count, ExpiresAt: c.expiresAt}
+	}
+	for k, g := range s.greylist {
+		dto.Greylist[k] = greylistDTO{Holder: g.holder, ExpiresAt: g.expiresAt}
+	}
+	for miner, byNonce := range s.nonceSeen {
+		cp := make(map[string]time.Time, len(byNonce))
+		for nonce, t := range byNonce {
+			cp[nonce] = t
+		}
+		dto.NonceSeen[miner] = cp
+	}
+
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(dto); err != nil {
+		return nil, fmt.Errorf("security: encode memory store snapshot: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// UnmarshalBinary restores state previously produced by MarshalBinary,
+// dropping any counter, greylist entry, or nonce record already past its
+// expiration so a pool that was down for a while doesn't come back
+// enforcing stale limits. A version mismatch is treated the same as a
+// decode failure: the caller (Inspector.LoadSnapshot) counts it as
+// corrupt and starts empty rather than risk misreading an incompatible
+// layout.
+func (s *memoryStore) UnmarshalBinary(data []byte) error {
+	var dto memoryStoreDTO
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&dto); err != nil {
+		return fmt.Errorf("security: decode memory store snapshot: %w", err)
+	}
+	if dto.Version != memoryStoreSnapshotVersion {
+		return fmt.Errorf("security: memory store snapshot version %d, expected %d", dto.Version, memoryStoreSnapshotVersion)
+	}
+
+	now := time.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for k, c := range dto.Counters {
+		if now.Before(c.ExpiresAt) {
+			s.counters[k] = memCounter{count: c.Count, expiresAt: c.ExpiresAt}
+		}
+	}
+	for k, g := range dto.Greylist {
+		if now.Before(g.ExpiresAt) {
+			s.greylist[k] = memGreylistEntry{holder: g.Holder, expiresAt: g.ExpiresAt}
+		}
+	}
+	for miner, byNonce := range dto.NonceSeen {
+		kept := make(map[string]time.Time)
+		for nonce, t := range byNonce {
+			if now.Sub(t) <= _windowSpan {
+				kept[nonce] = t
+			}
+		}
+		if len(kept) > 0 {
+			s.nonceSeen[miner] = kept
+		}
+	}
+	return nil
+}
+
+// redisStore backs InspectorStore with Redis so every pool instance
+// shares one view of request counters and greylisted subnets.
+// Nonce-reuse tracking uses an HSET per miner (field = nonce, value =
+// last-seen unix nano) plus a sorted-set index keyed by timestamp so
+// stale fields can be pruned by score range instead of scanning the
+// whole hash.
+type redisStore struct {
+	client *redis.Client
+}
+
+// NewRedisStore returns an InspectorStore backed by the given Redis
+// client. The caller owns the client's lifecycle (creation, close).
+func NewRedisStore(client *redis.Client) InspectorStore {
+	return &redisStore{client: client}
+}
+
+func (s *redisStore) Get(ctx context.Context, key string) (int64, error) {
+	n, err := s.client.Get(ctx, key).Int64()
+	if errors.Is(err, redis.Nil) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("security: redis get %q: %w", key, err)
+	}
+	return n, nil
+}
+
+// incrScript increments key and, only when that increment created it
+// (the resulting value is 1), sets its TTL — equivalent to INCR + "SET
+// EX only if new" without a round trip or a race between the two.
+var incrScript = redis.NewScript(`
+local v = redis.call("INCR", KEYS[1])
+if v == 1 then
+	redis.call("PEXPIRE", KEYS[1], ARGV[1])
+end
+return v
+`)
+
+func (s *redisStore) Incr(ctx context.Context, key string, ttl time.Duration) (int64, error) {
+	v, err := incrScript.Run(ctx, s.client, []string{key}, ttl.Milliseconds()).Int64()
+	if err != nil {
+		return 0, fmt.Errorf("security: redis incr %q: %w", key, err)
+	}
+	return v, nil
+}
+
+func (s *redisStore) ExpireAt(ctx context.Context, key string, at time.Time) error {
+	ok, err := s.client.ExpireAt(ctx, key, at).Result()
+	if err != nil {
+		return fmt.Errorf("security: redis expireat %q: %w", key, err)
+	}
+	if !ok {
+		return ErrLeaseLost
+	}
+	return nil
+}
+
+func greylistKey(subnet string) string {
+	return "inspector:greylist:" + subnet
+}
+
+// greylistScript sets KEYS[1] to ARGV[1] (the holder) with TTL ARGV[2]
+// only if the key doesn't already exist, then returns the holder
+// currently recorded — letting the caller tell whether it won the lease
+// or another instance already holds it, in one round trip.
+var greylistScript = redis.NewScript(`
+redis.call("SET", KEYS[1], ARGV[1], "NX", "PX", ARGV[2])
+return redis.call("GET", KEYS[1])
+`)
+
+func (s *redisStore) Greylist(ctx context.Context, subnet, holder string, ttl time.Duration) (bool, error) {
+	key := greylistKey(subnet)
+	owner, err := greylistScript.Run(ctx, s.client, []string{key}, holder, ttl.Milliseconds()).Text()
+	if err != nil {
+		return false, fmt.Errorf("security: redis greylist %q: %w", subnet, err)
+	}
+	return owner == holder, nil
+}
+
+func (s *redisStore) IsGreylisted(ctx context.Context, subnet string) (bool, error) {
+	n, err := s.client.Exists(ctx, greylistKey(subnet)).Result()
+	if err != nil {
+		return false, fmt.Errorf("security: redis is-greylisted %q: %w", subnet, err)
+	}
+	return n > 0, nil
+}
+
+func nonceHashKey(minerID string) string {
+	return "inspector:nonces:" + minerID
+}
+
+func nonceIndexKey(minerID string) string {
+	return "inspector:nonces:index:" + minerID
+}
+
+// nonceSeenScript reads the nonce's last-seen timestamp (if any) out of
+// the per-miner hash before overwriting it, then records the new
+// timestamp in both the hash and the sorted-set index used to prune
+// stale fields. Bundled into one script so the read-then-write is
+// atomic across concurrent submissions for the same miner.
+var nonceSeenScript = redis.NewScript(`
+local prev = redis.call("HGET", KEYS[1], ARGV[2])
+redis.call("HSET", KEYS[1], ARGV[2], ARGV[1])
+redis.call("ZADD", KEYS[2], ARGV[1], ARGV[2])
+return prev
+`)
+
+func (s *redisStore) NonceSeen(ctx context.Context, minerID, nonce string, now time.Time, window time.Duration) (bool, error) {
+	hashKey := nonceHashKey(minerID)
+	indexKey := nonceIndexKey(minerID)
+	nowNano := now.UnixNano()
+
+	// Prune index entries (and their matching hash fields) older than
+	// the window before checking this nonce, so a stale field doesn't
+	// look "seen" forever.
+	cutoff := now.Add(-window).UnixNano()
+	stale, err := s.client.ZRangeByScore(ctx, indexKey, &redis.ZRangeBy{
+		Min: "-inf",
+		Max: fmt.Sprintf("%d", cutoff),
+	}).Result()
+	if err != nil {
+		return false, fmt.Errorf("security: redis nonce prune scan %q: %w", minerID, err)
+	}
+	if len(stale) > 0 {
+		members := make([]interface{}, len(stale))
+		for i, v := range stale {
+			members[i] = v
+		}
+		pipe := s.client.Pipeline()
+		pipe.HDel(ctx, hashKey, stale...)
+		pipe.ZRem(ctx, indexKey, members...)
+		if _, err := pipe.Exec(ctx); err != nil {
+			return false, fmt.Errorf("security: redis nonce prune %q: %w", minerID, err)
+		}
+	}
+
+	prev, err := nonceSeenScript.Run(ctx, s.client, []string{hashKey, indexKey}, nowNano, nonce).Text()
+	if errors.Is(err, redis.Nil) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("security: redis nonce-seen %q: %w", minerID, err)
+	}
+	return prev != "", nil
+}
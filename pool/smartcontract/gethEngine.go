@@ -0,0 +1,95 @@
+package smartcontract
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+
+	"pool/config"
+	"pool/core"
+)
+
+// weiPerShareDiff scales a share's Diff into a wei payout amount.
+// core.Share carries no reward/amount field of its own — difficulty is
+// the only per-share quantity Pay has to work with — so GethEngine
+// treats the reward as directly proportional to it. Replace this with a
+// real PPLNS/FPPS payout calculation once one exists; today it only
+// needs to produce a well-formed, non-zero on-chain transfer.
+var weiPerShareDiff = big.NewInt(1_000_000_000) // 1 gwei per unit of Diff
+
+// GethEngine is the real smartcontract.Engine: it pays miners and
+// records shares on-chain through a PaymentClient/TxManager pair rather
+// than just logging, which is what MockEngine does.
+type GethEngine struct {
+	tm *TxManager
+	pc *PaymentClient
+}
+
+// NewGethEngine builds a GethEngine from cfg.SmartContract: it loads the
+// signing key from the configured keystore file, dials cfg.SmartContract.RPCURL,
+// and wires the resulting PaymentClient into a TxManager with the
+// configured confirmation depth.
+func NewGethEngine(cfg *config.Config) (*GethEngine, error) {
+	sc := cfg.SmartContract
+	pc, err := NewPaymentClientFromKeystore(sc.RPCURL, sc.ContractAddress, sc.KeystorePath, sc.KeystorePassphrase)
+	if err != nil {
+		return nil, fmt.Errorf("smartcontract: build payment client: %w", err)
+	}
+	tm := NewTxManager(pc, nil, sc.ConfirmationBlocks)
+	return &GethEngine{tm: tm, pc: pc}, nil
+}
+
+// Pay converts share.Diff into a wei amount via weiPerShareDiff and
+// sends it to share.WorkerID, which Pay treats as a hex-encoded payout
+// address — the repo has no separate miner->address registry, so the
+// worker identifier doubles as the on-chain recipient.
+func (g *GethEngine) Pay(ctx context.Context, share *core.Share) error {
+	if !common.IsHexAddress(share.WorkerID) {
+		return fmt.Errorf("smartcontract: worker id %q is not a payout address", share.WorkerID)
+	}
+	amount := weiAmountForDiff(share.Diff)
+	_, err := g.tm.Send(ctx, share.WorkerID, amount)
+	return err
+}
+
+// weiAmountForDiff applies weiPerShareDiff to a float64 difficulty,
+// rounding down to the nearest wei.
+func weiAmountForDiff(diff float64) *big.Int {
+	scaled := new(big.Float).Mul(big.NewFloat(diff), new(big.Float).SetInt(weiPerShareDiff))
+	amount, _ := scaled.Int(nil)
+	return amount
+}
+
+// SendReward routes through g.tm (TxManager), same as Pay and
+// SubmitShare below, rather than calling g.pc.SendReward directly: the
+// raw PaymentClient method doesn't serialize nonces or wait for a
+// confirmed receipt, which is exactly the nonce-race/no-confirmation
+// gap TxManager exists to close. This is what lets GethEngine satisfy
+// pool.RewardSender so production startup can wire container.PaymentClient
+// to a live backend instead of only MockEngine.
+func (g *GethEngine) SendReward(to string, amount *big.Int) (string, error) {
+	return g.tm.Send(context.Background(), to, amount)
+}
+
+// SubmitShare records a share on-chain via the contract's submitShare
+// method. Solidity has no floating-point type, so share.Diff (a
+// float64) is scaled into a fixed-point integer the same way Pay scales
+// it into a wei amount, and the string ShareID/Nonce fields are hashed
+// into bytes32 values with Keccak256, since the ABI has no notion of an
+// arbitrary-length string identifier for this call.
+func (g *GethEngine) SubmitShare(worker core.WorkerIdentifier, share *core.Share) error {
+	if !common.IsHexAddress(string(worker)) {
+		return fmt.Errorf("smartcontract: worker id %q is not a payout address", worker)
+	}
+	workerAddr := common.HexToAddress(string(worker))
+	shareID := crypto.Keccak256Hash([]byte(share.ID))
+	nonce := crypto.Keccak256Hash([]byte(share.Nonce))
+	scaledDiff := weiAmountForDiff(share.Diff)
+
+	ctx := context.Background()
+	_, err := g.tm.SendCall(ctx, "submitShare", workerAddr, shareID, nonce, scaledDiff)
+	return err
+}
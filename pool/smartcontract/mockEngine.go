@@ -3,8 +3,10 @@ package smartcontract
 import (
 	"context"
 	"fmt"
+	"log"
 	"time"
 
+	"pool/config"
 	"pool/core"
 )
 
@@ -15,9 +17,21 @@ type Engine interface {
 
 type MockEngine struct{}
 
-// Init retorna uma instância do MockEngine
-func Init(_ interface{}) (Engine, error) {
-	return &MockEngine{}, nil
+// Init builds the configured Engine. Unless an operator has explicitly
+// set cfg.SmartContract.DryRun to false and supplied RPC/keystore/contract
+// details, it returns the MockEngine, which only logs — real on-chain
+// payouts are opt-in, not the default.
+func Init(cfg *config.Config) (Engine, error) {
+	sc := cfg.SmartContract
+	if sc.DryRun || sc.RPCURL == "" || sc.KeystorePath == "" || sc.ContractAddress == "" {
+		return &MockEngine{}, nil
+	}
+	engine, err := NewGethEngine(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("smartcontract: init geth engine: %w", err)
+	}
+	log.Printf("smartcontract: using live GethEngine against %s (contract %s)", sc.RPCURL, sc.ContractAddress)
+	return engine, nil
 }
 
 func (m *MockEngine) Pay(ctx context.Context, share *core.Share) error {
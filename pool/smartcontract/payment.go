@@ -9,12 +9,15 @@ import (
 	"log"
 	"math/big"
 	"math/rand"
+	"os"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/accounts/keystore"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/ethclient"
@@ -25,6 +28,7 @@ var paymentABIJSON []byte
 type PaymentClient struct {
 	client       *ethclient.Client
 	contract     *bind.BoundContract
+	mu           sync.Mutex // guards auth, which Transact mutates in place
 	auth         *bind.TransactOpts
 	address      common.Address
 	chainID      *big.Int
@@ -33,6 +37,33 @@ type PaymentClient struct {
 }
 
 func NewPaymentClient(rpcURL, contractAddrHex, privateKeyHex string) (*PaymentClient, error) {
+	pk, err := crypto.HexToECDSA(strings.TrimPrefix(privateKeyHex, "0x"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid private key: %w", err)
+	}
+	return newPaymentClientWithKey(rpcURL, contractAddrHex, pk)
+}
+
+// NewPaymentClientFromKeystore builds a PaymentClient whose signing key is
+// decrypted from a geth keystore file instead of passed as a raw hex
+// string, so the payout signer's key can live at rest encrypted under an
+// operator-held passphrase rather than in plaintext config.
+func NewPaymentClientFromKeystore(rpcURL, contractAddrHex, keystorePath, passphrase string) (*PaymentClient, error) {
+	raw, err := os.ReadFile(keystorePath)
+	if err != nil {
+		return nil, fmt.Errorf("read keystore %s: %w", keystorePath, err)
+	}
+	key, err := keystore.DecryptKey(raw, passphrase)
+	if err != nil {
+		return nil, fmt.Errorf("decrypt keystore %s: %w", keystorePath, err)
+	}
+	return newPaymentClientWithKey(rpcURL, contractAddrHex, key.PrivateKey)
+}
+
+// newPaymentClientWithKey is the shared constructor body for
+// NewPaymentClient and NewPaymentClientFromKeystore, which differ only in
+// how they obtain pk.
+func newPaymentClientWithKey(rpcURL, contractAddrHex string, pk *ecdsa.PrivateKey) (*PaymentClient, error) {
 	conn, err := ethclient.Dial(rpcURL)
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect RPC: %w", err)
@@ -51,10 +82,6 @@ func NewPaymentClient(rpcURL, contractAddrHex, privateKeyHex string) (*PaymentCl
 	}
 	contractAddr := common.HexToAddress(contractAddrHex)
 
-	pk, err := crypto.HexToECDSA(strings.TrimPrefix(privateKeyHex, "0x"))
-	if err != nil {
-		return nil, fmt.Errorf("invalid private key: %w", err)
-	}
 	pub, ok := pk.Public().(*ecdsa.PublicKey)
 	if !ok {
 		return nil, errors.New("cannot cast public key")
@@ -105,14 +132,16 @@ func (pc *PaymentClient) SendReward(to string, amount *big.Int) (txHash string,
 	}
 	recipient := common.HexToAddress(to)
 
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	pc.mu.Lock()
 	pc.auth.Nonce = nil
 	pc.auth.Value = big.NewInt(0)
 	pc.auth.GasLimit = 0
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-	defer cancel()
 	pc.auth.Context = ctx
-
 	tx, err := pc.contract.Transact(pc.auth, "payMiner", recipient, amount)
+	pc.mu.Unlock()
 	if err != nil {
 		if revertReason := detectRevertReason(ctx, pc.client, pc.contractAddr, pc.address, "payMiner", recipient, amount); revertReason != "" {
 			return "", fmt.Errorf("contract transact (payMiner) reverted: %s", revertReason)
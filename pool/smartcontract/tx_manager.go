@@ -0,0 +1,350 @@
+package smartcontract
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// TxStatus describes where a submitted transaction is in its lifecycle.
+type TxStatus string
+
+const (
+	TxStatusPending   TxStatus = "pending"
+	TxStatusConfirmed TxStatus = "confirmed"
+	TxStatusReverted  TxStatus = "reverted"
+	TxStatusReplaced  TxStatus = "replaced"
+)
+
+// minBumpPercent is the smallest fee increase geth's mempool will accept
+// for a replacement transaction at the same nonce.
+const minBumpPercent = 10
+
+// TxManager wraps a PaymentClient with per-sender nonce tracking,
+// fee-bumped replacement of stuck transactions, and background receipt
+// polling, so the payout pipeline can fire-and-forget a reward and be
+// notified later via OnConfirmed/OnReverted rather than blocking on
+// confirmation inline.
+type TxManager struct {
+	pc *PaymentClient
+
+	mu     sync.Mutex
+	nonces map[common.Address]uint64
+
+	// maxGasFeeCapWei caps how high GasFeeCap is allowed to go, including
+	// across replacements; nil means no ceiling.
+	maxGasFeeCapWei *big.Int
+
+	pollInterval  time.Duration
+	replaceAfter  time.Duration
+	confirmations uint64
+
+	// OnConfirmed/OnReverted are invoked from the per-tx polling
+	// goroutine once a receipt is mined and has accumulated
+	// `confirmations` blocks of depth.
+	OnConfirmed func(txHash string, receipt *types.Receipt)
+	OnReverted  func(txHash string, receipt *types.Receipt)
+}
+
+// NewTxManager builds a TxManager around pc. maxGasFeeCapWei may be nil
+// to leave gas fees uncapped; confirmations is how many blocks past
+// inclusion a receipt must sit before OnConfirmed fires.
+func NewTxManager(pc *PaymentClient, maxGasFeeCapWei *big.Int, confirmations uint64) *TxManager {
+	return &TxManager{
+		pc:              pc,
+		nonces:          make(map[common.Address]uint64),
+		maxGasFeeCapWei: maxGasFeeCapWei,
+		pollInterval:    5 * time.Second,
+		replaceAfter:    2 * time.Minute,
+		confirmations:   confirmations,
+	}
+}
+
+// nextNonce returns the next nonce to use for sender, tracking it
+// in-memory after the first call (seeded from PendingNonceAt) so
+// concurrent sends from the same sender don't race for the same nonce.
+func (tm *TxManager) nextNonce(ctx context.Context, sender common.Address) (uint64, error) {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+
+	if n, ok := tm.nonces[sender]; ok {
+		tm.nonces[sender] = n + 1
+		return n, nil
+	}
+
+	pending, err := tm.pc.client.PendingNonceAt(ctx, sender)
+	if err != nil {
+		return 0, fmt.Errorf("txmanager: fetch pending nonce: %w", err)
+	}
+	tm.nonces[sender] = pending + 1
+	return pending, nil
+}
+
+// clampFeeCap enforces maxGasFeeCapWei, if configured.
+func (tm *TxManager) clampFeeCap(feeCap *big.Int) *big.Int {
+	if tm.maxGasFeeCapWei == nil || feeCap.Cmp(tm.maxGasFeeCapWei) <= 0 {
+		return feeCap
+	}
+	return new(big.Int).Set(tm.maxGasFeeCapWei)
+}
+
+// suggestFees queries the chain for a base fee tip, returning
+// (gasTipCap, gasFeeCap) clamped to maxGasFeeCapWei.
+func (tm *TxManager) suggestFees(ctx context.Context) (tipCap, feeCap *big.Int, err error) {
+	header, err := tm.pc.client.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("txmanager: fetch header: %w", err)
+	}
+	if header.BaseFee == nil {
+		return nil, nil, fmt.Errorf("txmanager: chain does not support EIP-1559")
+	}
+
+	tipCap, err = tm.pc.client.SuggestGasTipCap(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("txmanager: suggest gas tip cap: %w", err)
+	}
+
+	feeCap = new(big.Int).Mul(header.BaseFee, big.NewInt(2))
+	feeCap.Add(feeCap, tipCap)
+	feeCap = tm.clampFeeCap(feeCap)
+
+	return tipCap, feeCap, nil
+}
+
+// bumpFees increases tipCap/feeCap by at least minBumpPercent, the
+// minimum geth requires to accept a replacement at the same nonce.
+func bumpFees(tipCap, feeCap *big.Int) (newTipCap, newFeeCap *big.Int) {
+	return bumpFeesByPercent(tipCap, feeCap, minBumpPercent)
+}
+
+// bumpFeesByPercent increases tipCap/feeCap by pct percent, computed in
+// parts-per-thousand so a fractional percentage like
+// replacementBumpPercent (12.5) doesn't need float math this close to
+// wei amounts.
+func bumpFeesByPercent(tipCap, feeCap *big.Int, pct float64) (newTipCap, newFeeCap *big.Int) {
+	permille := big.NewInt(int64((100 + pct) * 10))
+	bump := func(v *big.Int) *big.Int {
+		bumped := new(big.Int).Mul(v, permille)
+		return bumped.Div(bumped, big.NewInt(1000))
+	}
+	return bump(tipCap), bump(feeCap)
+}
+
+// Send submits an EIP-1559 payMiner transaction to recipient and starts
+// a background goroutine that polls for its receipt and replaces it
+// with a fee-bumped resubmission if it hasn't been mined within
+// replaceAfter. It's a thin wrapper over SendCall for the one call site
+// that predates the generic method/args form.
+func (tm *TxManager) Send(ctx context.Context, to string, amount *big.Int) (txHash string, err error) {
+	if !common.IsHexAddress(to) {
+		return "", fmt.Errorf("txmanager: invalid recipient: %s", to)
+	}
+	return tm.SendCall(ctx, "payMiner", common.HexToAddress(to), amount)
+}
+
+// retryAction classifies what a failed send attempt calls for next, so
+// SendCall's retry loop can react the way the mempool actually needs
+// instead of retrying every failure identically.
+type retryAction int
+
+const (
+	retryFatal retryAction = iota
+	retryRefetchNonce
+	retryBumpFee
+	retryTransient
+)
+
+// replacementBumpPercent is the tip bump SendCall applies when the node
+// rejects a send as underpriced against an already-pending tx at the
+// same nonce — distinct from minBumpPercent, which governs watch's
+// proactive replacement of a transaction that's merely taking too long.
+const replacementBumpPercent = 12.5
+
+// classifyTxError inspects a send error's message to decide how SendCall
+// should retry. Matching on substrings is what geth (and most other
+// clients') JSON-RPC error strings give us; there's no typed error for
+// any of these conditions.
+func classifyTxError(err error) retryAction {
+	if err == nil {
+		return retryFatal
+	}
+	msg := strings.ToLower(err.Error())
+
+	switch {
+	case strings.Contains(msg, "nonce too low"):
+		return retryRefetchNonce
+	case strings.Contains(msg, "replacement transaction underpriced"),
+		strings.Contains(msg, "underpriced"):
+		return retryBumpFee
+	case strings.Contains(msg, "timeout"),
+		strings.Contains(msg, "connection refused"),
+		strings.Contains(msg, "eof"),
+		strings.Contains(msg, "temporarily unavailable"):
+		return retryTransient
+	default:
+		return retryFatal
+	}
+}
+
+// forgetNonce drops sender's cached nonce, forcing the next nextNonce
+// call to re-fetch it from the chain via PendingNonceAt rather than
+// keep incrementing a value that's apparently out of sync.
+func (tm *TxManager) forgetNonce(sender common.Address) {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+	delete(tm.nonces, sender)
+}
+
+const (
+	maxSendAttempts = 5
+	baseRetryDelay  = 500 * time.Millisecond
+)
+
+// SendCall submits an EIP-1559 transaction calling method on the
+// contract with args, retrying with exponential backoff on the
+// transient failure modes classifyTxError recognizes: a stale cached
+// nonce is refetched, a mempool rejection for being underpriced gets
+// its tip bumped by replacementBumpPercent, and bare transient RPC
+// failures (timeouts, dropped connections) are simply retried. Anything
+// else is treated as fatal and returned immediately.
+//
+// On success it starts a background goroutine that polls for the
+// transaction's receipt and replaces it with a fee-bumped resubmission
+// if it hasn't been mined within replaceAfter.
+func (tm *TxManager) SendCall(ctx context.Context, method string, args ...interface{}) (txHash string, err error) {
+	tipCap, feeCap, err := tm.suggestFees(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	delay := baseRetryDelay
+	for attempt := 0; attempt < maxSendAttempts; attempt++ {
+		nonce, nerr := tm.nextNonce(ctx, tm.pc.address)
+		if nerr != nil {
+			return "", nerr
+		}
+
+		tx, serr := tm.submitCall(ctx, nonce, tipCap, feeCap, method, args...)
+		if serr == nil {
+			go tm.watch(nonce, tx, method, args...)
+			return tx.Hash().Hex(), nil
+		}
+
+		switch classifyTxError(serr) {
+		case retryRefetchNonce:
+			tm.forgetNonce(tm.pc.address)
+		case retryBumpFee:
+			tipCap, feeCap = bumpFeesByPercent(tipCap, feeCap, replacementBumpPercent)
+			feeCap = tm.clampFeeCap(feeCap)
+		case retryTransient:
+			// fall through to backoff below
+		default:
+			return "", serr
+		}
+
+		if attempt == maxSendAttempts-1 {
+			return "", fmt.Errorf("txmanager: send %s: giving up after %d attempts: %w", method, maxSendAttempts, serr)
+		}
+
+		select {
+		case <-ctx.Done():
+			return "", ctx.Err()
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+	return "", fmt.Errorf("txmanager: send %s: exhausted retries", method)
+}
+
+// submitCall locks pc.auth just long enough to build and send one
+// transaction, since bind.TransactOpts is mutated in place by Transact.
+func (tm *TxManager) submitCall(ctx context.Context, nonce uint64, tipCap, feeCap *big.Int, method string, args ...interface{}) (*types.Transaction, error) {
+	tm.pc.mu.Lock()
+	defer tm.pc.mu.Unlock()
+
+	tm.pc.auth.Context = ctx
+	tm.pc.auth.Nonce = new(big.Int).SetUint64(nonce)
+	tm.pc.auth.Value = big.NewInt(0)
+	tm.pc.auth.GasLimit = 0
+	tm.pc.auth.GasTipCap = tipCap
+	tm.pc.auth.GasFeeCap = feeCap
+
+	tx, err := tm.pc.contract.Transact(tm.pc.auth, method, args...)
+	if err != nil {
+		return nil, fmt.Errorf("txmanager: submit %s: %w", method, err)
+	}
+	return tx, nil
+}
+
+// watch polls for tx's receipt, replacing it with a fee-bumped
+// resubmission of the same method/args at the same nonce if it's still
+// pending past replaceAfter, until it is mined and has accumulated the
+// configured confirmation depth.
+func (tm *TxManager) watch(nonce uint64, tx *types.Transaction, method string, args ...interface{}) {
+	ctx := context.Background()
+	deadline := time.Now().Add(tm.replaceAfter)
+	current := tx
+
+	ticker := time.NewTicker(tm.pollInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		receipt, err := tm.pc.client.TransactionReceipt(ctx, current.Hash())
+		if err == nil {
+			if tm.confirmations > 0 {
+				if !tm.hasEnoughConfirmations(ctx, receipt) {
+					continue
+				}
+			}
+			tm.reportOutcome(current.Hash().Hex(), receipt)
+			return
+		}
+
+		if time.Now().Before(deadline) {
+			continue
+		}
+
+		replacement, rerr := tm.replace(ctx, nonce, current, method, args...)
+		if rerr != nil {
+			// Leave the old tx in flight; try again next tick.
+			continue
+		}
+		current = replacement
+		deadline = time.Now().Add(tm.replaceAfter)
+	}
+}
+
+func (tm *TxManager) hasEnoughConfirmations(ctx context.Context, receipt *types.Receipt) bool {
+	latest, err := tm.pc.client.BlockNumber(ctx)
+	if err != nil {
+		return false
+	}
+	return latest >= receipt.BlockNumber.Uint64()+tm.confirmations
+}
+
+func (tm *TxManager) reportOutcome(txHash string, receipt *types.Receipt) {
+	if receipt.Status == types.ReceiptStatusSuccessful {
+		if tm.OnConfirmed != nil {
+			tm.OnConfirmed(txHash, receipt)
+		}
+		return
+	}
+	if tm.OnReverted != nil {
+		tm.OnReverted(txHash, receipt)
+	}
+}
+
+// replace resubmits method/args at the same nonce with fees bumped by
+// at least minBumpPercent, which is what lets it displace the original
+// in the mempool instead of being rejected as an underpriced duplicate.
+func (tm *TxManager) replace(ctx context.Context, nonce uint64, stuck *types.Transaction, method string, args ...interface{}) (*types.Transaction, error) {
+	tipCap, feeCap := bumpFees(stuck.GasTipCap(), stuck.GasFeeCap())
+	feeCap = tm.clampFeeCap(feeCap)
+	return tm.submitCall(ctx, nonce, tipCap, feeCap, method, args...)
+}
@@ -0,0 +1,89 @@
+package stratum
+
+import "encoding/json"
+
+// Request is a Stratum V1 line-delimited JSON-RPC request/notification.
+// Notifications (server -> client, e.g. mining.notify) omit ID.
+type Request struct {
+	ID     interface{}     `json:"id"`
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params"`
+}
+
+// Response is a Stratum V1 JSON-RPC response.
+type Response struct {
+	ID     interface{} `json:"id"`
+	Result interface{} `json:"result,omitempty"`
+	Error  interface{} `json:"error"`
+}
+
+func newResponse(id interface{}, result interface{}) Response {
+	return Response{ID: id, Result: result, Error: nil}
+}
+
+func newErrorResponse(id interface{}, code int, message string) Response {
+	return Response{ID: id, Result: nil, Error: []interface{}{code, message, nil}}
+}
+
+type authorizeParams []string
+
+type submitParams struct {
+	WorkerName  string
+	JobID       string
+	ExtraNonce2 string
+	NTime       string
+	Nonce       string
+}
+
+func parseSubmitParams(raw json.RawMessage) (submitParams, error) {
+	var arr []string
+	if err := json.Unmarshal(raw, &arr); err != nil {
+		return submitParams{}, err
+	}
+	p := submitParams{}
+	if len(arr) > 0 {
+		p.WorkerName = arr[0]
+	}
+	if len(arr) > 1 {
+		p.JobID = arr[1]
+	}
+	if len(arr) > 2 {
+		p.ExtraNonce2 = arr[2]
+	}
+	if len(arr) > 3 {
+		p.NTime = arr[3]
+	}
+	if len(arr) > 4 {
+		p.Nonce = arr[4]
+	}
+	return p, nil
+}
+
+func parseAuthorizeParams(raw json.RawMessage) (authorizeParams, error) {
+	var arr authorizeParams
+	if err := json.Unmarshal(raw, &arr); err != nil {
+		return nil, err
+	}
+	return arr, nil
+}
+
+// notifyParams builds the mining.notify payload for a job broadcast.
+// Field order follows the de-facto Stratum V1 layout; fields we don't
+// model yet (merkle branches, version/nbits/ntime) are sent as placeholders.
+func notifyParams(jobID, data, target string, cleanJobs bool) []interface{} {
+	return []interface{}{
+		jobID,
+		data,
+		"",         // coinb1
+		"",         // coinb2
+		[]string{}, // merkle branches
+		"00000002", // version
+		target,
+		"00000000", // ntime
+		cleanJobs,
+	}
+}
+
+func setDifficultyParams(diff float64) []interface{} {
+	return []interface{}{diff}
+}
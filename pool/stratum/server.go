@@ -0,0 +1,430 @@
+// Package stratum implements a Stratum mining server. It exposes the
+// V1 line-delimited JSON-RPC protocol (mining.subscribe/authorize/notify/
+// submit/set_difficulty) over TCP, with an optional V2 binary framing
+// mode (see v2.go) selected via config and secured with a Noise NX
+// handshake. Accepted shares are handed to the existing
+// core.ShareProcessor so both the HTTP and Stratum front-ends share one
+// validation/fraud/store pipeline. A Server with no ShareProcessor
+// configured (see ListenAndServe) instead validates mining.submit
+// directly against an Inspector and a JobManager.
+package stratum
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/flynn/noise"
+
+	"pool/core"
+	"pool/logs"
+	"pool/security"
+	"pool/utils"
+)
+
+// Config controls how the listener behaves.
+type Config struct {
+	Addr          string
+	V2Enabled     bool
+	V2Addr        string
+	JobTTL        time.Duration
+	DefaultTarget string
+}
+
+// Server accepts miner TCP connections and feeds submitted shares into
+// the shared ShareProcessor/JobManager.
+type Server struct {
+	cfg       Config
+	jobs      *core.JobManager
+	processor *core.ShareProcessor
+	insp      *security.Inspector
+
+	// workers and corePool back the Stratum V2 listener: an authorized
+	// V2 channel registers its *core.Worker in workers (if set), and
+	// submitted shares are converted to types.Share and run through
+	// corePool.ProcessShare. A nil corePool rejects every
+	// SubmitSharesStandard instead of skipping validation; a nil
+	// workers just means channels aren't tracked in a registry.
+	workers  *core.WorkerRegistry
+	corePool *core.Pool
+
+	// noiseStatic is the pool's long-lived X25519 identity key for the
+	// V2 Noise NX handshake. Zero-value (no WithNoiseStaticKey call)
+	// means V2 connections are refused.
+	noiseStatic noise.DHKey
+
+	v2ChanSeq uint32
+
+	mu       sync.RWMutex
+	sessions map[*session]struct{}
+
+	v2mu       sync.RWMutex
+	v2sessions map[*v2Session]struct{}
+
+	ln     net.Listener
+	lnV2   net.Listener
+	closed chan struct{}
+}
+
+// NewServer wires a Stratum server on top of the pool's existing
+// JobManager and ShareProcessor.
+func NewServer(cfg Config, jobs *core.JobManager, processor *core.ShareProcessor) *Server {
+	if cfg.DefaultTarget == "" {
+		cfg.DefaultTarget = "0000ffffffffffffffffffffffffffffffffffffffffffffffffffffffffff"
+	}
+	if cfg.JobTTL == 0 {
+		cfg.JobTTL = 60 * time.Second
+	}
+	return &Server{
+		cfg:        cfg,
+		jobs:       jobs,
+		processor:  processor,
+		sessions:   make(map[*session]struct{}),
+		v2sessions: make(map[*v2Session]struct{}),
+		closed:     make(chan struct{}),
+	}
+}
+
+// WithInspector attaches insp to s so handleSubmit runs every
+// mining.submit through Inspector.Evaluate before (or instead of, when s
+// has no ShareProcessor) accepting it. Returns s for chaining off
+// NewServer.
+func (s *Server) WithInspector(insp *security.Inspector) *Server {
+	s.insp = insp
+	return s
+}
+
+// WithWorkers attaches the WorkerRegistry V2 channels register into.
+// Returns s for chaining off NewServer.
+func (s *Server) WithWorkers(workers *core.WorkerRegistry) *Server {
+	s.workers = workers
+	return s
+}
+
+// WithPool attaches the core.Pool V2 SubmitSharesStandard frames are
+// validated against via ProcessShare, and subscribes to its
+// PoolCore.OnJobDispatched hook so operator-pushed jobs fan out to every
+// V2 session as NewMiningJob/SetNewPrevHash. Returns s for chaining off
+// NewServer.
+func (s *Server) WithPool(p *core.Pool) *Server {
+	s.corePool = p
+	p.Engine.OnJobDispatched = s.broadcastV2Job
+	return s
+}
+
+// WithNoiseStaticKey attaches the pool's long-lived Noise identity key,
+// without which V2 connections are refused. Returns s for chaining off
+// NewServer.
+func (s *Server) WithNoiseStaticKey(key noise.DHKey) *Server {
+	s.noiseStatic = key
+	return s
+}
+
+// ListenAndServe starts a Stratum V1 listener on addr wired directly to
+// jm and insp, with no ShareProcessor/ShareStore involved: mining.submit
+// is validated via insp.Evaluate followed by jm.ValidateShare, and
+// mining.notify broadcasts ride jm.OnJobCreated. It's the entry point
+// for a minimal deployment that only needs job-aware validation and
+// antifraud, not persistence/consensus/payout hooks; pool/cmd/main.go
+// uses NewServer+(*Server).ListenAndServe(ctx) instead, since it also
+// wants a ShareStore and vardiff wired through a ShareProcessor.
+func ListenAndServe(addr string, jm *core.JobManager, insp *security.Inspector) error {
+	srv := NewServer(Config{Addr: addr}, jm, nil).WithInspector(insp)
+	jm.OnJobCreated = func(job *core.Job) {
+		srv.BroadcastJob(job, true)
+	}
+	return srv.ListenAndServe(context.Background())
+}
+
+// ListenAndServe starts accepting Stratum V1 connections (and, if
+// configured, a second listener for V2) until the context is cancelled.
+func (s *Server) ListenAndServe(ctx context.Context) error {
+	ln, err := net.Listen("tcp", s.cfg.Addr)
+	if err != nil {
+		return fmt.Errorf("stratum: listen %s: %w", s.cfg.Addr, err)
+	}
+	s.ln = ln
+	logs.WithFields(map[string]interface{}{"addr": s.cfg.Addr}).Info("Stratum V1 listener started")
+
+	if s.cfg.V2Enabled {
+		lnV2, err := net.Listen("tcp", s.cfg.V2Addr)
+		if err != nil {
+			ln.Close()
+			return fmt.Errorf("stratum: listen v2 %s: %w", s.cfg.V2Addr, err)
+		}
+		s.lnV2 = lnV2
+		logs.WithFields(map[string]interface{}{"addr": s.cfg.V2Addr}).Info("Stratum V2 listener started")
+		go s.acceptLoop(ctx, lnV2, s.serveV2Conn)
+	}
+
+	return s.acceptLoop(ctx, ln, s.serveV1Conn)
+}
+
+func (s *Server) acceptLoop(ctx context.Context, ln net.Listener, serve func(context.Context, net.Conn)) error {
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			select {
+			case <-s.closed:
+				return nil
+			default:
+			}
+			select {
+			case <-ctx.Done():
+				return nil
+			default:
+			}
+			logs.WithFields(map[string]interface{}{"error": err.Error()}).Warn("Stratum accept failed")
+			continue
+		}
+		go serve(ctx, conn)
+	}
+}
+
+// Shutdown closes the listeners and disconnects all sessions.
+func (s *Server) Shutdown(ctx context.Context) error {
+	close(s.closed)
+	if s.ln != nil {
+		s.ln.Close()
+	}
+	if s.lnV2 != nil {
+		s.lnV2.Close()
+	}
+
+	s.mu.Lock()
+	for sess := range s.sessions {
+		sess.conn.Close()
+	}
+	s.mu.Unlock()
+
+	s.v2mu.Lock()
+	for sess := range s.v2sessions {
+		sess.conn.Close()
+	}
+	s.v2mu.Unlock()
+	return nil
+}
+
+// SetDifficulty pushes mining.set_difficulty to every session belonging
+// to workerID. Wired as core.DifficultyController.OnRetarget.
+func (s *Server) SetDifficulty(workerID core.WorkerIdentifier, diff float64) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for sess := range s.sessions {
+		if sess.workerID != string(workerID) {
+			continue
+		}
+		sess.difficulty = diff
+		sess.sendSetDifficulty()
+	}
+}
+
+// BroadcastJob pushes a mining.notify to every subscribed session.
+func (s *Server) BroadcastJob(job *core.Job, cleanJobs bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for sess := range s.sessions {
+		sess.notifyJob(job, cleanJobs)
+	}
+}
+
+type session struct {
+	conn       net.Conn
+	writer     *bufio.Writer
+	writeMu    sync.Mutex
+	subscribed bool
+	workerID   string
+	authorized bool
+	difficulty float64
+	server     *Server
+}
+
+func (s *Server) serveV1Conn(ctx context.Context, conn net.Conn) {
+	sess := &session{
+		conn:       conn,
+		writer:     bufio.NewWriter(conn),
+		difficulty: 1,
+		server:     s,
+	}
+
+	s.mu.Lock()
+	s.sessions[sess] = struct{}{}
+	s.mu.Unlock()
+
+	defer func() {
+		s.mu.Lock()
+		delete(s.sessions, sess)
+		s.mu.Unlock()
+		conn.Close()
+	}()
+
+	scanner := bufio.NewScanner(conn)
+	scanner.Buffer(make([]byte, 0, 4096), 1<<16)
+
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		var req Request
+		if err := json.Unmarshal(line, &req); err != nil {
+			sess.writeResponse(newErrorResponse(nil, 20, "invalid JSON-RPC frame"))
+			continue
+		}
+		sess.handle(ctx, req)
+	}
+}
+
+func (sess *session) handle(ctx context.Context, req Request) {
+	switch req.Method {
+	case "mining.subscribe":
+		sess.subscribed = true
+		extraNonce1 := utils.GenerateRandomHex(4)
+		result := []interface{}{
+			[][2]string{{"mining.set_difficulty", utils.GenerateUUID()}, {"mining.notify", utils.GenerateUUID()}},
+			extraNonce1,
+			4,
+		}
+		sess.writeResponse(newResponse(req.ID, result))
+		sess.sendSetDifficulty()
+
+	case "mining.authorize":
+		params, err := parseAuthorizeParams(req.Params)
+		if err != nil || len(params) == 0 {
+			sess.writeResponse(newErrorResponse(req.ID, 21, "malformed authorize params"))
+			return
+		}
+		sess.workerID = params[0]
+		sess.authorized = true
+		sess.writeResponse(newResponse(req.ID, true))
+		logs.WithFields(map[string]interface{}{"worker_id": sess.workerID}).Info("Stratum worker authorized")
+
+	case "mining.submit":
+		sess.handleSubmit(ctx, req)
+
+	default:
+		sess.writeResponse(newErrorResponse(req.ID, 20, "unknown method: "+req.Method))
+	}
+}
+
+func (sess *session) handleSubmit(ctx context.Context, req Request) {
+	if !sess.authorized {
+		sess.writeResponse(newErrorResponse(req.ID, 24, "unauthorized worker"))
+		return
+	}
+
+	params, err := parseSubmitParams(req.Params)
+	if err != nil {
+		sess.writeResponse(newErrorResponse(req.ID, 21, "malformed submit params"))
+		return
+	}
+
+	hash := params.ExtraNonce2 + params.NTime
+
+	if sess.server.insp != nil {
+		if sess.server.processor == nil {
+			sess.handleSubmitDirect(ctx, req, params, hash)
+			return
+		}
+		ip, _, err := net.SplitHostPort(sess.conn.RemoteAddr().String())
+		if err != nil {
+			ip = sess.conn.RemoteAddr().String()
+		}
+		if verdict := sess.server.insp.Evaluate(ctx, sess.workerID, ip, params.Nonce, hash, time.Now()); verdict.Flagged {
+			sess.writeResponse(newErrorResponse(req.ID, 23, "blocked by antifraud: "+verdict.Reason))
+			return
+		}
+	}
+
+	share := core.Share{
+		JobID:     params.JobID,
+		WorkerID:  sess.workerID,
+		Nonce:     params.Nonce,
+		Hash:      hash,
+		Timestamp: time.Now(),
+	}
+	share.ID = utils.GenerateUUID()
+
+	result := sess.server.processor.ProcessContext(ctx, share, sess.server.cfg.DefaultTarget, sess.server.cfg.JobTTL)
+	if !result.Valid {
+		sess.writeResponse(newErrorResponse(req.ID, 23, result.Description))
+		return
+	}
+	sess.writeResponse(newResponse(req.ID, true))
+}
+
+// handleSubmitDirect validates a mining.submit against sess.server's
+// Inspector and JobManager instead of a ShareProcessor — the path
+// ListenAndServe wires up. insp.Evaluate runs the same rate-limit/
+// nonce-reuse/hash-reuse checks the HTTP front-end runs before a share
+// ever reaches job validation, and jm.ValidateShare checks the result
+// against the job's actual recorded data/target.
+func (sess *session) handleSubmitDirect(ctx context.Context, req Request, params submitParams, hash string) {
+	ip, _, err := net.SplitHostPort(sess.conn.RemoteAddr().String())
+	if err != nil {
+		ip = sess.conn.RemoteAddr().String()
+	}
+
+	verdict := sess.server.insp.Evaluate(ctx, sess.workerID, ip, params.Nonce, hash, time.Now())
+	if verdict.Flagged {
+		sess.writeResponse(newErrorResponse(req.ID, 23, "blocked by antifraud: "+verdict.Reason))
+		return
+	}
+
+	if !sess.server.jobs.ValidateShare(ctx, params.JobID, params.Nonce, hash) {
+		sess.writeResponse(newErrorResponse(req.ID, 23, "invalid share"))
+		return
+	}
+	sess.writeResponse(newResponse(req.ID, true))
+}
+
+func (sess *session) notifyJob(job *core.Job, cleanJobs bool) {
+	if !sess.subscribed {
+		return
+	}
+	notif := Request{
+		Method: "mining.notify",
+		Params: mustMarshal(notifyParams(job.ID, job.Data, job.Target, cleanJobs)),
+	}
+	sess.writeFrame(notif)
+}
+
+func (sess *session) sendSetDifficulty() {
+	notif := Request{
+		Method: "mining.set_difficulty",
+		Params: mustMarshal(setDifficultyParams(sess.difficulty)),
+	}
+	sess.writeFrame(notif)
+}
+
+func (sess *session) writeResponse(resp Response) {
+	sess.writeRaw(resp)
+}
+
+func (sess *session) writeFrame(req Request) {
+	sess.writeRaw(req)
+}
+
+func (sess *session) writeRaw(v interface{}) {
+	encoded, err := json.Marshal(v)
+	if err != nil {
+		logs.WithFields(map[string]interface{}{"error": err.Error()}).Error("Stratum: failed to encode frame")
+		return
+	}
+
+	sess.writeMu.Lock()
+	defer sess.writeMu.Unlock()
+	sess.writer.Write(encoded)
+	sess.writer.WriteByte('\n')
+	sess.writer.Flush()
+}
+
+func mustMarshal(v interface{}) json.RawMessage {
+	b, _ := json.Marshal(v)
+	return b
+}
@@ -0,0 +1,249 @@
+package stratum
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"pool/core"
+	"pool/logs"
+	"pool/types"
+	"pool/utils"
+)
+
+// v2Session is one Noise-encrypted Stratum V2 connection. Unlike the V1
+// session (which is identified by a single worker name authorized up
+// front), a V2 connection opens one or more channels; this server only
+// implements standard channels, one per connection, so sess doubles as
+// that channel's state.
+type v2Session struct {
+	conn    net.Conn
+	writeMu sync.Mutex
+	send    cipherState
+	recv    cipherState
+
+	server *Server
+
+	channelID  uint32
+	workerID   string
+	worker     *core.Worker
+	authorized bool
+}
+
+// cipherState is the subset of *noise.CipherState this file depends on,
+// so tests could substitute a fake without pulling in the real Noise
+// handshake.
+type cipherState interface {
+	Encrypt(out, ad, plaintext []byte) ([]byte, error)
+	Decrypt(out, ad, ciphertext []byte) ([]byte, error)
+}
+
+func (s *Server) nextV2ChannelID() uint32 {
+	return atomic.AddUint32(&s.v2ChanSeq, 1)
+}
+
+// serveV2Conn terminates one Stratum V2 TCP connection: it runs the
+// Noise NX handshake, then dispatches SetupConnection/
+// OpenStandardMiningChannel/SubmitSharesStandard frames until the
+// connection closes or ctx is cancelled.
+func (s *Server) serveV2Conn(ctx context.Context, conn net.Conn) {
+	defer conn.Close()
+	log := logs.WithFields(map[string]interface{}{"remote": conn.RemoteAddr().String()})
+
+	if len(s.noiseStatic.Private) == 0 {
+		log.Error("Stratum V2: no Noise static key configured (see WithNoiseStaticKey), dropping connection")
+		return
+	}
+
+	send, recv, err := noiseHandshake(conn, s.noiseStatic)
+	if err != nil {
+		log.WithFields(map[string]interface{}{"error": err.Error()}).Warn("Stratum V2: noise handshake failed")
+		return
+	}
+
+	sess := &v2Session{conn: conn, send: send, recv: recv, server: s}
+
+	s.v2mu.Lock()
+	s.v2sessions[sess] = struct{}{}
+	s.v2mu.Unlock()
+	defer func() {
+		s.v2mu.Lock()
+		delete(s.v2sessions, sess)
+		s.v2mu.Unlock()
+		if sess.worker != nil {
+			sess.worker.Disconnect()
+		}
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		default:
+		}
+
+		msgType, payload, err := sess.readMessage()
+		if err != nil {
+			return
+		}
+		sess.handle(ctx, msgType, payload)
+	}
+}
+
+func (sess *v2Session) handle(ctx context.Context, msgType byte, payload []byte) {
+	switch msgType {
+	case msgSetupConnection:
+		sess.handleSetupConnection(payload)
+	case msgOpenStandardMiningChannel:
+		sess.handleOpenStandardMiningChannel(payload)
+	case msgSubmitSharesStandard:
+		sess.handleSubmitSharesStandard(ctx, payload)
+	default:
+		logs.WithFields(map[string]interface{}{"msg_type": msgType}).Warn("Stratum V2: unknown message type")
+	}
+}
+
+func (sess *v2Session) handleSetupConnection(payload []byte) {
+	var req v2SetupConnection
+	if err := json.Unmarshal(payload, &req); err != nil {
+		sess.writeMessage(msgSetupConnectionError, v2SetupConnectionError{ErrorCode: "malformed-setup-connection"})
+		return
+	}
+	sess.writeMessage(msgSetupConnectionSuccess, v2SetupConnectionSuccess{UsedVersion: 2})
+}
+
+func (sess *v2Session) handleOpenStandardMiningChannel(payload []byte) {
+	var req v2OpenStandardMiningChannel
+	if err := json.Unmarshal(payload, &req); err != nil || req.UserIdentity == "" {
+		sess.writeMessage(msgSubmitSharesError, v2SubmitSharesError{ErrorCode: "malformed-open-channel"})
+		return
+	}
+
+	sess.channelID = sess.server.nextV2ChannelID()
+	sess.workerID = req.UserIdentity
+	sess.authorized = true
+	sess.worker = core.NewWorker(req.UserIdentity)
+	if sess.server.workers != nil {
+		sess.server.workers.Add(sess.worker)
+	}
+
+	sess.writeMessage(msgOpenStandardMiningChannelSuccess, v2OpenStandardMiningChannelSuccess{
+		ChannelID: sess.channelID,
+		Target:    sess.server.cfg.DefaultTarget,
+	})
+	logs.WithFields(map[string]interface{}{"worker_id": sess.workerID, "channel_id": sess.channelID}).Info("Stratum V2 channel opened")
+}
+
+// handleSubmitSharesStandard converts a SubmitSharesStandard frame into
+// a types.Share and runs it through corePool.ProcessShare — the same
+// fraud/validation path core.Pool's HTTP front-end uses — then maps the
+// ShareResult back onto SubmitSharesSuccess/Error.
+func (sess *v2Session) handleSubmitSharesStandard(ctx context.Context, payload []byte) {
+	if !sess.authorized {
+		sess.writeMessage(msgSubmitSharesError, v2SubmitSharesError{ErrorCode: "channel-not-open"})
+		return
+	}
+
+	var req v2SubmitSharesStandard
+	if err := json.Unmarshal(payload, &req); err != nil {
+		sess.writeMessage(msgSubmitSharesError, v2SubmitSharesError{ChannelID: sess.channelID, ErrorCode: "malformed-submit"})
+		return
+	}
+
+	if sess.server.corePool == nil {
+		sess.writeMessage(msgSubmitSharesError, v2SubmitSharesError{ChannelID: req.ChannelID, ErrorCode: "pool-not-configured"})
+		return
+	}
+
+	ip, _, splitErr := net.SplitHostPort(sess.conn.RemoteAddr().String())
+	if splitErr != nil {
+		ip = sess.conn.RemoteAddr().String()
+	}
+
+	share := types.Share{
+		ID:        utils.GenerateUUID(),
+		WorkerID:  sess.workerID,
+		JobID:     req.JobID,
+		Nonce:     req.Nonce,
+		Hash:      req.Hash,
+		Timestamp: time.Now(),
+		IP:        ip,
+	}
+
+	// core.Pool.ProcessShare takes core.Share; types.Share exists
+	// separately as this protocol's wire-level representation, so it's
+	// adapted field-for-field rather than making ProcessShare accept
+	// either type.
+	result, err := sess.server.corePool.ProcessShare(ctx, core.Share{
+		ID:        share.ID,
+		JobID:     share.JobID,
+		WorkerID:  share.WorkerID,
+		Nonce:     share.Nonce,
+		Hash:      share.Hash,
+		Diff:      share.Diff,
+		Timestamp: share.Timestamp,
+		IP:        share.IP,
+	})
+	if err != nil || !result.Valid {
+		sess.writeMessage(msgSubmitSharesError, v2SubmitSharesError{ChannelID: sess.channelID, ErrorCode: result.Description})
+		return
+	}
+	sess.writeMessage(msgSubmitSharesSuccess, v2SubmitSharesSuccess{ChannelID: sess.channelID})
+}
+
+// broadcastV2Job is wired as core.PoolCore.OnJobDispatched by WithPool.
+// It translates one operator-pushed Job into a NewMiningJob plus
+// SetNewPrevHash for every open V2 channel, mirroring what BroadcastJob
+// does for V1's single mining.notify.
+func (s *Server) broadcastV2Job(job *core.Job) {
+	s.v2mu.RLock()
+	defer s.v2mu.RUnlock()
+	for sess := range s.v2sessions {
+		if !sess.authorized {
+			continue
+		}
+		sess.writeMessage(msgNewMiningJob, v2NewMiningJob{
+			ChannelID: sess.channelID,
+			JobID:     job.ID,
+			Data:      job.Data,
+		})
+		sess.writeMessage(msgSetNewPrevHash, v2SetNewPrevHash{
+			JobID:    job.ID,
+			PrevHash: job.Target,
+		})
+	}
+}
+
+func (sess *v2Session) writeMessage(msgType byte, v interface{}) {
+	plain, err := encodeV2Message(msgType, v)
+	if err != nil {
+		logs.WithFields(map[string]interface{}{"error": err.Error()}).Error("Stratum V2: failed to encode message")
+		return
+	}
+
+	sess.writeMu.Lock()
+	defer sess.writeMu.Unlock()
+	ct, err := sess.send.Encrypt(nil, nil, plain)
+	if err != nil {
+		logs.WithFields(map[string]interface{}{"error": err.Error()}).Error("Stratum V2: failed to encrypt message")
+		return
+	}
+	if err := writeFrame(sess.conn, ct); err != nil {
+		logs.WithFields(map[string]interface{}{"error": err.Error()}).Warn("Stratum V2: failed to write frame")
+	}
+}
+
+func (sess *v2Session) readMessage() (byte, []byte, error) {
+	ct, err := readFrame(sess.conn)
+	if err != nil {
+		return 0, nil, err
+	}
+	plain, err := sess.recv.Decrypt(nil, nil, ct)
+	if err != nil {
+		return 0, nil, err
+	}
+	return decodeV2Message(plain)
+}
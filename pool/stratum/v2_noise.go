@@ -0,0 +1,70 @@
+package stratum
+
+import (
+	"crypto/rand"
+	"fmt"
+	"io"
+
+	"github.com/flynn/noise"
+)
+
+// noiseCipherSuite is the cipher suite the SV2 spec mandates for the
+// transport handshake: X25519 for key agreement, ChaCha20-Poly1305 for
+// the AEAD, SHA-256 for the transcript hash.
+var noiseCipherSuite = noise.NewCipherSuite(noise.DH25519, noise.CipherChaChaPoly, noise.HashSHA256)
+
+// GenerateNoiseStaticKey creates a fresh X25519 keypair for a pool that
+// doesn't already persist one. Callers that want a stable identity
+// across restarts (so returning miners don't see it rotate) should
+// generate this once out of band and feed the same key into every
+// Server via WithNoiseStaticKey.
+func GenerateNoiseStaticKey() (noise.DHKey, error) {
+	key, err := noiseCipherSuite.GenerateKeypair(rand.Reader)
+	if err != nil {
+		return noise.DHKey{}, fmt.Errorf("stratum: generate noise static key: %w", err)
+	}
+	return key, nil
+}
+
+// noiseHandshake runs the responder side of a Noise NX handshake over
+// rw, authenticating the pool to the miner with staticKey. NX is a
+// two-message pattern: the initiator (miner) sends an ephemeral key
+// with no static key of its own, and the responder (pool) replies with
+// its own ephemeral key plus its static key, encrypted to the
+// now-shared secret — so a miner that completes the handshake has
+// implicitly verified it's talking to whoever holds staticKey's private
+// half, while the miner itself stays anonymous at the transport layer.
+//
+// flynn/noise returns the two CipherStates in Split order on both sides:
+// the first carries initiator->responder traffic, the second
+// responder->initiator. As responder, the pool decrypts miner->pool
+// traffic with the first and encrypts pool->miner traffic with the
+// second; the results are returned here as (send, recv).
+func noiseHandshake(rw io.ReadWriter, staticKey noise.DHKey) (send *noise.CipherState, recv *noise.CipherState, err error) {
+	hs, err := noise.NewHandshakeState(noise.Config{
+		CipherSuite:   noiseCipherSuite,
+		Pattern:       noise.HandshakeNX,
+		Initiator:     false,
+		StaticKeypair: staticKey,
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("stratum: init noise handshake: %w", err)
+	}
+
+	msg1, err := readFrame(rw)
+	if err != nil {
+		return nil, nil, fmt.Errorf("stratum: read noise message 1: %w", err)
+	}
+	if _, _, _, err := hs.ReadMessage(nil, msg1); err != nil {
+		return nil, nil, fmt.Errorf("stratum: noise message 1: %w", err)
+	}
+
+	msg2, recv, send, err := hs.WriteMessage(nil, nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("stratum: noise message 2: %w", err)
+	}
+	if err := writeFrame(rw, msg2); err != nil {
+		return nil, nil, fmt.Errorf("stratum: write noise message 2: %w", err)
+	}
+	return send, recv, nil
+}
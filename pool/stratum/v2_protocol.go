@@ -0,0 +1,142 @@
+package stratum
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// Stratum V2 message type identifiers for the base mining protocol.
+// The real spec scopes these within an extension_type namespace; this
+// server only speaks the base extension, so a single byte is enough to
+// dispatch.
+const (
+	msgSetupConnection                  byte = 0x00
+	msgSetupConnectionSuccess           byte = 0x01
+	msgSetupConnectionError             byte = 0x02
+	msgOpenStandardMiningChannel        byte = 0x10
+	msgOpenStandardMiningChannelSuccess byte = 0x11
+	msgNewMiningJob                     byte = 0x20
+	msgSetNewPrevHash                   byte = 0x21
+	msgSubmitSharesStandard             byte = 0x30
+	msgSubmitSharesSuccess              byte = 0x31
+	msgSubmitSharesError                byte = 0x32
+)
+
+// v2SetupConnection is sent once by the miner right after the Noise
+// handshake completes, identifying the firmware/device on the other end
+// of the wire.
+type v2SetupConnection struct {
+	Protocol   string `json:"protocol"`
+	MinVersion uint16 `json:"min_version"`
+	MaxVersion uint16 `json:"max_version"`
+	VendorID   string `json:"vendor_id"`
+	Firmware   string `json:"firmware"`
+	DeviceID   string `json:"device_id"`
+}
+
+type v2SetupConnectionSuccess struct {
+	UsedVersion uint16 `json:"used_version"`
+}
+
+type v2SetupConnectionError struct {
+	ErrorCode string `json:"error_code"`
+}
+
+// v2OpenStandardMiningChannel requests a channel to submit shares on;
+// UserIdentity is the miner/worker name, matching mining.authorize's
+// params[0] on the V1 side.
+type v2OpenStandardMiningChannel struct {
+	UserIdentity    string  `json:"user_identity"`
+	NominalHashrate float64 `json:"nominal_hashrate"`
+}
+
+type v2OpenStandardMiningChannelSuccess struct {
+	ChannelID uint32 `json:"channel_id"`
+	Target    string `json:"target"`
+}
+
+// v2NewMiningJob and v2SetNewPrevHash split what V1's single mining.notify
+// carries into the two messages SV2 uses. Data mirrors the opaque
+// blob JobManager/Job already hands V1 sessions.
+type v2NewMiningJob struct {
+	ChannelID uint32 `json:"channel_id"`
+	JobID     string `json:"job_id"`
+	Data      string `json:"data"`
+}
+
+type v2SetNewPrevHash struct {
+	JobID    string `json:"job_id"`
+	PrevHash string `json:"prev_hash"`
+}
+
+type v2SubmitSharesStandard struct {
+	ChannelID uint32 `json:"channel_id"`
+	JobID     string `json:"job_id"`
+	Nonce     string `json:"nonce"`
+	Hash      string `json:"hash"`
+}
+
+type v2SubmitSharesSuccess struct {
+	ChannelID uint32 `json:"channel_id"`
+}
+
+type v2SubmitSharesError struct {
+	ChannelID uint32 `json:"channel_id"`
+	ErrorCode string `json:"error_code"`
+}
+
+// writeFrame/readFrame carry one opaque, length-prefixed blob: a 4-byte
+// big-endian length followed by that many bytes. It's used both for the
+// plaintext Noise handshake messages and, once the handshake completes,
+// for Noise-encrypted application messages — the blob is ciphertext at
+// that point, and the 1-byte message type plus JSON payload it decrypts
+// to are only meaningful to the two ends that hold the transport keys.
+func writeFrame(w io.Writer, payload []byte) error {
+	var hdr [4]byte
+	binary.BigEndian.PutUint32(hdr[:], uint32(len(payload)))
+	if _, err := w.Write(hdr[:]); err != nil {
+		return err
+	}
+	_, err := w.Write(payload)
+	return err
+}
+
+func readFrame(r io.Reader) ([]byte, error) {
+	var hdr [4]byte
+	if _, err := io.ReadFull(r, hdr[:]); err != nil {
+		return nil, err
+	}
+	n := binary.BigEndian.Uint32(hdr[:])
+	if n > 1<<20 {
+		return nil, fmt.Errorf("stratum: v2 frame too large: %d bytes", n)
+	}
+	buf := make([]byte, n)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return nil, err
+	}
+	return buf, nil
+}
+
+// encodeV2Message packs msgType and v (JSON-encoded) into the plaintext
+// that writeMessage then encrypts and hands to writeFrame.
+func encodeV2Message(msgType byte, v interface{}) ([]byte, error) {
+	payload, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("stratum: encode v2 message %#x: %w", msgType, err)
+	}
+	plain := make([]byte, 1+len(payload))
+	plain[0] = msgType
+	copy(plain[1:], payload)
+	return plain, nil
+}
+
+// decodeV2Message splits plaintext produced by encodeV2Message back into
+// its message type and raw JSON payload.
+func decodeV2Message(plain []byte) (msgType byte, payload []byte, err error) {
+	if len(plain) < 1 {
+		return 0, nil, fmt.Errorf("stratum: empty v2 message")
+	}
+	return plain[0], plain[1:], nil
+}
@@ -0,0 +1,90 @@
+// Package telemetry wires up OpenTelemetry tracing for the pool process.
+// It is intentionally thin: Init builds a tracer provider backed by an
+// OTLP/gRPC exporter and registers it as the global provider, so any
+// package can just call otel.Tracer(name) and get spans flowing to the
+// configured collector.
+package telemetry
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"pool/config"
+	"pool/logs"
+)
+
+// TracerName is used as the instrumentation scope for every span the
+// pool emits, so a collector query can filter on a single name.
+const TracerName = "pool"
+
+// ShutdownFunc flushes and stops the tracer provider. Callers should
+// invoke it during graceful shutdown, with a bounded context.
+type ShutdownFunc func(ctx context.Context) error
+
+// Init configures global tracing from cfg.Telemetry. When telemetry is
+// disabled it installs a no-op shutdown so callers don't need to branch.
+func Init(ctx context.Context, cfg config.Config) (ShutdownFunc, error) {
+	if !cfg.Telemetry.Enabled {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	exporterOpts := []otlptracegrpc.Option{
+		otlptracegrpc.WithEndpoint(cfg.Telemetry.OTLPEndpoint),
+	}
+	if cfg.Telemetry.Insecure {
+		exporterOpts = append(exporterOpts, otlptracegrpc.WithInsecure())
+	}
+
+	exporter, err := otlptracegrpc.New(ctx, exporterOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("telemetry: create OTLP exporter: %w", err)
+	}
+
+	res, err := resource.New(ctx,
+		resource.WithAttributes(semconv.ServiceName(cfg.Telemetry.ServiceName)),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("telemetry: build resource: %w", err)
+	}
+
+	ratio := cfg.Telemetry.SampleRatio
+	if ratio <= 0 {
+		ratio = 1.0
+	}
+
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(ratio))),
+	)
+
+	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{}, propagation.Baggage{},
+	))
+
+	logs.WithFields(map[string]interface{}{
+		"endpoint": cfg.Telemetry.OTLPEndpoint,
+	}).Info("OpenTelemetry tracing enabled")
+
+	return func(shutdownCtx context.Context) error {
+		flushCtx, cancel := context.WithTimeout(shutdownCtx, 5*time.Second)
+		defer cancel()
+		return tp.Shutdown(flushCtx)
+	}, nil
+}
+
+// Tracer returns the pool's shared tracer. Safe to call even when
+// telemetry is disabled - it then resolves to the global no-op tracer.
+func Tracer() trace.Tracer {
+	return otel.Tracer(TracerName)
+}
@@ -6,7 +6,10 @@ import (
 
 type contextKey string
 
-const requestIDKey contextKey = "request_id"
+const (
+	requestIDKey contextKey = "request_id"
+	claimsKey    contextKey = "claims"
+)
 
 func GetRequestID(ctx context.Context) string {
 	val := ctx.Value(requestIDKey)
@@ -19,3 +22,18 @@ func GetRequestID(ctx context.Context) string {
 func SetRequestID(ctx context.Context, id string) context.Context {
 	return context.WithValue(ctx, requestIDKey, id)
 }
+
+// SetClaims attaches the parsed JWT claims from withJWTAuth to ctx.
+// Typed as interface{} (rather than a concrete claims struct) so this
+// package doesn't need to import pool/security, which already imports
+// pool/utils.
+func SetClaims(ctx context.Context, claims interface{}) context.Context {
+	return context.WithValue(ctx, claimsKey, claims)
+}
+
+// GetClaims retrieves the claims attached by SetClaims, or nil if none
+// were attached (e.g. a request that only passed through legacy bearer
+// auth). Callers type-assert to their concrete claims type.
+func GetClaims(ctx context.Context) interface{} {
+	return ctx.Value(claimsKey)
+}
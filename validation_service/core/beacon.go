@@ -0,0 +1,188 @@
+package core
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// BeaconSource fetches the public randomness for a drand round. Rounds
+// are monotonically increasing and published on a fixed period, so a
+// miner can't pre-compute a share against a round that hasn't been
+// published yet.
+type BeaconSource interface {
+	Entry(ctx context.Context, round uint64) ([]byte, error)
+}
+
+// BeaconNetwork binds a BeaconSource to the range of rounds it serves,
+// starting at StartRound, so a drand network (or chain hash) can be
+// swapped out for a newer one without invalidating history: rounds
+// before the new network's StartRound keep resolving against the old
+// one.
+type BeaconNetwork struct {
+	Name        string
+	StartRound  uint64
+	GenesisTime time.Time
+	Period      time.Duration
+	Source      BeaconSource
+}
+
+// expectedRound estimates the latest round a well-behaved drand network
+// should have published by now, from its genesis time and period,
+// without needing a network round-trip.
+func (n BeaconNetwork) expectedRound(now time.Time) uint64 {
+	if n.Period <= 0 || now.Before(n.GenesisTime) {
+		return n.StartRound
+	}
+	return n.StartRound + uint64(now.Sub(n.GenesisTime)/n.Period)
+}
+
+// beaconCacheLimit bounds how many (round -> randomness) entries are
+// kept in memory; since rounds only increase, evicting the smallest
+// round approximates a FIFO without needing a full LRU.
+const beaconCacheLimit = 512
+
+// DrandClient fetches beacon entries from a drand HTTP relay
+// (https://drand.love), caching entries in memory since the same round
+// is typically checked by many shares in quick succession.
+type DrandClient struct {
+	baseURL    string
+	httpClient *http.Client
+
+	mu    sync.Mutex
+	cache map[uint64][]byte
+}
+
+// NewDrandClient builds a client against a drand HTTP relay's base URL,
+// e.g. "https://api.drand.sh/<chain-hash>".
+func NewDrandClient(baseURL string) *DrandClient {
+	return &DrandClient{
+		baseURL:    baseURL,
+		httpClient: &http.Client{Timeout: 5 * time.Second},
+		cache:      make(map[uint64][]byte),
+	}
+}
+
+type drandResponse struct {
+	Round      uint64 `json:"round"`
+	Randomness string `json:"randomness"`
+}
+
+// Entry implements BeaconSource, fetching GET {baseURL}/public/{round}.
+func (d *DrandClient) Entry(ctx context.Context, round uint64) ([]byte, error) {
+	d.mu.Lock()
+	if cached, ok := d.cache[round]; ok {
+		d.mu.Unlock()
+		return cached, nil
+	}
+	d.mu.Unlock()
+
+	url := fmt.Sprintf("%s/public/%d", d.baseURL, round)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("drand: build request: %w", err)
+	}
+
+	resp, err := d.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("drand: fetch round %d: %w", round, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("drand: round %d returned status %d", round, resp.StatusCode)
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("drand: read response: %w", err)
+	}
+
+	var parsed drandResponse
+	if err := json.Unmarshal(body, &parsed); err != nil {
+		return nil, fmt.Errorf("drand: invalid response for round %d: %w", round, err)
+	}
+
+	randomness, err := hex.DecodeString(parsed.Randomness)
+	if err != nil {
+		return nil, fmt.Errorf("drand: invalid randomness hex for round %d: %w", round, err)
+	}
+
+	d.storeCache(round, randomness)
+	return randomness, nil
+}
+
+func (d *DrandClient) storeCache(round uint64, randomness []byte) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if len(d.cache) >= beaconCacheLimit {
+		var oldest uint64
+		first := true
+		for r := range d.cache {
+			if first || r < oldest {
+				oldest = r
+				first = false
+			}
+		}
+		delete(d.cache, oldest)
+	}
+	d.cache[round] = randomness
+}
+
+// networkFor returns the beacon network responsible for round: the
+// configured network with the highest StartRound that is still <= round.
+func networkFor(networks []BeaconNetwork, round uint64) (BeaconNetwork, bool) {
+	best := -1
+	for i, n := range networks {
+		if n.StartRound <= round && (best == -1 || n.StartRound > networks[best].StartRound) {
+			best = i
+		}
+	}
+	if best == -1 {
+		return BeaconNetwork{}, false
+	}
+	return networks[best], true
+}
+
+// sortBeaconNetworks orders networks by StartRound ascending, in place;
+// NewBeaconValidator uses it before storing them in Beacons.
+func sortBeaconNetworks(networks []BeaconNetwork) {
+	sort.Slice(networks, func(i, j int) bool { return networks[i].StartRound < networks[j].StartRound })
+}
+
+// deriveBeaconTarget mixes a drand beacon entry into baseTargetHex so
+// the effective target for round can't be predicted before the beacon
+// for that round is published: target = baseTarget XOR SHA256(beacon || round).
+func deriveBeaconTarget(baseTargetHex string, beacon []byte, round uint64) (string, error) {
+	baseBytes, err := hex.DecodeString(baseTargetHex)
+	if err != nil {
+		return "", fmt.Errorf("beacon: invalid base target hex: %w", err)
+	}
+
+	var roundBytes [8]byte
+	binary.BigEndian.PutUint64(roundBytes[:], round)
+
+	mix := sha256.New()
+	mix.Write(beacon)
+	mix.Write(roundBytes[:])
+	mixed := mix.Sum(nil)
+
+	base := new(big.Int).SetBytes(baseBytes)
+	xorKey := new(big.Int).SetBytes(mixed)
+	// Only mix in as many bytes as the base target has, so the result
+	// stays the same width/shape as baseTargetHex.
+	xorKey.Mod(xorKey, new(big.Int).Lsh(big.NewInt(1), uint(len(baseBytes)*8)))
+
+	result := new(big.Int).Xor(base, xorKey)
+	return fmt.Sprintf("%0*x", len(baseBytes)*2, result), nil
+}
@@ -0,0 +1,172 @@
+package core
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+// HashAlgorithm computes the proof-of-work hash for a share's block
+// template + nonce under one specific coin's rules, so ValidatorEngine
+// isn't hardcoded to a single hash function.
+type HashAlgorithm interface {
+	Name() string
+	Hash(blockTemplate, nonce string) (string, error)
+}
+
+// AlgorithmRegistry resolves a Share's declared Algorithm name to the
+// HashAlgorithm that verifies it.
+type AlgorithmRegistry struct {
+	mu   sync.RWMutex
+	algs map[string]HashAlgorithm
+}
+
+// NewAlgorithmRegistry builds an empty registry.
+func NewAlgorithmRegistry() *AlgorithmRegistry {
+	return &AlgorithmRegistry{algs: make(map[string]HashAlgorithm)}
+}
+
+// Register adds algo, keyed by its Name(), replacing any prior
+// registration under the same name.
+func (r *AlgorithmRegistry) Register(algo HashAlgorithm) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.algs[algo.Name()] = algo
+}
+
+// Get looks up an algorithm by name.
+func (r *AlgorithmRegistry) Get(name string) (HashAlgorithm, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	algo, ok := r.algs[name]
+	return algo, ok
+}
+
+// DefaultAlgorithmRegistry registers every algorithm this pool ships
+// support for. Ethash and RandomX register too, so a share declaring
+// Algorithm="ethash"/"randomx" gets a clear "not implemented" error
+// instead of silently falling through to sha256d.
+func DefaultAlgorithmRegistry() *AlgorithmRegistry {
+	r := NewAlgorithmRegistry()
+	r.Register(SHA256D{})
+	r.Register(Scrypt{N: 1024, R: 1, P: 1})
+	r.Register(Ethash{})
+	r.Register(RandomX{})
+	return r
+}
+
+// SHA256D is the Bitcoin-family proof of work: two rounds of SHA-256
+// over blockTemplate+nonce.
+type SHA256D struct{}
+
+func (SHA256D) Name() string { return "sha256d" }
+
+func (SHA256D) Hash(blockTemplate, nonce string) (string, error) {
+	first := sha256.Sum256([]byte(blockTemplate + nonce))
+	second := sha256.Sum256(first[:])
+	return hex.EncodeToString(second[:]), nil
+}
+
+// Scrypt is the Litecoin-family proof of work: scrypt(nonce, blockTemplate, N, r, p).
+// N/R/P default to Litecoin's parameters (1024, 1, 1) when left zero.
+type Scrypt struct {
+	N, R, P int
+}
+
+func (Scrypt) Name() string { return "scrypt" }
+
+func (s Scrypt) Hash(blockTemplate, nonce string) (string, error) {
+	n, r, p := s.N, s.R, s.P
+	if n == 0 {
+		n = 1024
+	}
+	if r == 0 {
+		r = 1
+	}
+	if p == 0 {
+		p = 1
+	}
+	sum, err := scrypt.Key([]byte(nonce), []byte(blockTemplate), n, r, p, 32)
+	if err != nil {
+		return "", fmt.Errorf("scrypt: %w", err)
+	}
+	return hex.EncodeToString(sum), nil
+}
+
+// Ethash verifies Ethereum-family proof of work. A faithful
+// implementation needs the per-epoch DAG (gigabytes, generated via
+// go-ethereum's consensus/ethash) or at least its light-client cache;
+// neither is available in this build environment, so Hash fails
+// loudly rather than silently returning a wrong digest.
+type Ethash struct{}
+
+func (Ethash) Name() string { return "ethash" }
+
+func (Ethash) Hash(blockTemplate, nonce string) (string, error) {
+	return "", errors.New("ethash: dataset-backed verification not available in this build")
+}
+
+// RandomX verifies Monero-family proof of work. A faithful
+// implementation needs the RandomX dataset/VM (a cgo binding to
+// tevador/RandomX, or a pure-Go port); neither is available in this
+// build environment.
+type RandomX struct{}
+
+func (RandomX) Name() string { return "randomx" }
+
+func (RandomX) Hash(blockTemplate, nonce string) (string, error) {
+	return "", errors.New("randomx: VM-backed verification not available in this build")
+}
+
+// compareHexTarget reports whether hashHex satisfies targetHex.
+// targetHex may be Bitcoin's compact 4-byte "nBits" form or a raw
+// 256-bit hex value (how Ethash/RandomX-style targets are expressed);
+// expandTarget picks the right interpretation from its length.
+func compareHexTarget(hashHex, targetHex string) (bool, error) {
+	if !isHex256(hashHex) {
+		return false, fmt.Errorf("hash não é hex-256: %s", hashHex)
+	}
+	expanded, err := expandTarget(targetHex)
+	if err != nil {
+		return false, err
+	}
+	h := new(big.Int)
+	if _, ok := h.SetString(hashHex, 16); !ok {
+		return false, fmt.Errorf("hash hex inválido: %s", hashHex)
+	}
+	return h.Cmp(expanded) == -1, nil
+}
+
+// expandTarget accepts either a compact 8-hex-char "nBits" target
+// (Bitcoin-style: 1 exponent byte + 3 mantissa bytes) or a raw
+// 64-hex-char 256-bit target, returning the expanded big.Int.
+func expandTarget(targetHex string) (*big.Int, error) {
+	switch len(targetHex) {
+	case 8:
+		raw, err := hex.DecodeString(targetHex)
+		if err != nil {
+			return nil, fmt.Errorf("nBits inválido: %w", err)
+		}
+		exponent := raw[0]
+		mantissa := new(big.Int).SetBytes(raw[1:])
+		if exponent <= 3 {
+			mantissa.Rsh(mantissa, uint(8*(3-exponent)))
+			return mantissa, nil
+		}
+		mantissa.Lsh(mantissa, uint(8*(exponent-3)))
+		return mantissa, nil
+	case 64:
+		t := new(big.Int)
+		if _, ok := t.SetString(targetHex, 16); !ok {
+			return nil, fmt.Errorf("target hex inválido: %s", targetHex)
+		}
+		return t, nil
+	default:
+		return nil, fmt.Errorf("tamanho de target não suportado: %d", len(targetHex))
+	}
+}
@@ -0,0 +1,118 @@
+package core
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+)
+
+func TestSHA256DMatchesDoubleSHA256(t *testing.T) {
+	algo := SHA256D{}
+	hash, err := algo.Hash("block-template", "deadbeef")
+	if err != nil {
+		t.Fatalf("Hash: %v", err)
+	}
+
+	first := sha256.Sum256([]byte("block-template" + "deadbeef"))
+	second := sha256.Sum256(first[:])
+	want := hex.EncodeToString(second[:])
+
+	if hash != want {
+		t.Errorf("hash = %s, want %s", hash, want)
+	}
+	if !isHex256(hash) {
+		t.Errorf("hash %s is not hex-256", hash)
+	}
+}
+
+func TestScryptDeterministic(t *testing.T) {
+	algo := Scrypt{N: 1024, R: 1, P: 1}
+	h1, err := algo.Hash("block-template", "cafebabe")
+	if err != nil {
+		t.Fatalf("Hash: %v", err)
+	}
+	h2, err := algo.Hash("block-template", "cafebabe")
+	if err != nil {
+		t.Fatalf("Hash: %v", err)
+	}
+	if h1 != h2 {
+		t.Errorf("scrypt hash not deterministic: %s != %s", h1, h2)
+	}
+	if !isHex256(h1) {
+		t.Errorf("hash %s is not hex-256", h1)
+	}
+
+	h3, err := algo.Hash("block-template", "00000001")
+	if err != nil {
+		t.Fatalf("Hash: %v", err)
+	}
+	if h1 == h3 {
+		t.Errorf("different nonces produced the same hash")
+	}
+}
+
+func TestEthashNotImplemented(t *testing.T) {
+	_, err := Ethash{}.Hash("block-template", "deadbeef")
+	if err == nil {
+		t.Fatal("expected error, got nil")
+	}
+}
+
+func TestRandomXNotImplemented(t *testing.T) {
+	_, err := RandomX{}.Hash("block-template", "deadbeef")
+	if err == nil {
+		t.Fatal("expected error, got nil")
+	}
+}
+
+func TestDefaultAlgorithmRegistry(t *testing.T) {
+	reg := DefaultAlgorithmRegistry()
+	for _, name := range []string{"sha256d", "scrypt", "ethash", "randomx"} {
+		if _, ok := reg.Get(name); !ok {
+			t.Errorf("registry missing algorithm %q", name)
+		}
+	}
+	if _, ok := reg.Get("unknown"); ok {
+		t.Error("registry unexpectedly resolved unknown algorithm")
+	}
+}
+
+func TestCompareHexTargetRawForm(t *testing.T) {
+	target := "00000000ffffffffffffffffffffffffffffffffffffffffffffffffffffffff"
+
+	below := "0000000000000000000000000000000000000000000000000000000000000001"
+	ok, err := compareHexTarget(below, target)
+	if err != nil {
+		t.Fatalf("compareHexTarget: %v", err)
+	}
+	if !ok {
+		t.Error("expected hash below target to be valid")
+	}
+
+	above := "ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff"
+	ok, err = compareHexTarget(above, target)
+	if err != nil {
+		t.Fatalf("compareHexTarget: %v", err)
+	}
+	if ok {
+		t.Error("expected hash above target to be invalid")
+	}
+}
+
+func TestCompareHexTargetCompactForm(t *testing.T) {
+	// nBits 0x1d00ffff is Bitcoin's genesis target.
+	hash := "0000000000000000000000000000000000000000000000000000000000000001"
+	ok, err := compareHexTarget(hash, "1d00ffff")
+	if err != nil {
+		t.Fatalf("compareHexTarget: %v", err)
+	}
+	if !ok {
+		t.Error("expected a near-zero hash to satisfy the genesis target")
+	}
+}
+
+func TestExpandTargetRejectsBadLength(t *testing.T) {
+	if _, err := expandTarget("abcd"); err == nil {
+		t.Error("expected error for unsupported target length")
+	}
+}
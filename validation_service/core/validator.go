@@ -1,9 +1,9 @@
 package core
 
 import (
-	"crypto/sha256"
+	"context"
 	"encoding/hex"
-	"math/big"
+	"fmt"
 	"os"
 	"strings"
 	"time"
@@ -12,6 +12,10 @@ import (
 	"validation_service/types"
 )
 
+// defaultAlgorithm is used for shares that don't declare one, matching
+// the engine's historical hardcoded SHA-256d behavior.
+const defaultAlgorithm = "sha256d"
+
 // ValidationResult representa o resultado completo da validação de um share.
 type ValidationResult struct {
 	IsValid      bool
@@ -26,19 +30,54 @@ type ValidatorEngine struct {
 	MinLatencyMS int64  // Latência mínima esperada entre shares
 	TargetHex    string // Target hexadecimal (simulando blockchains reais)
 	DevMode      bool   // Modo desenvolvimento: validação leniente
+
+	// Beacons, when non-empty, switches ValidateShare into drand-backed
+	// mode: each share must declare the round its target was derived
+	// from, and the effective target is baseTarget mixed with that
+	// round's beacon entry instead of the static TargetHex. Keyed by
+	// StartRound so a network upgrade doesn't invalidate old rounds.
+	Beacons []BeaconNetwork
+
+	// MaxRoundStaleness rejects shares declaring a round more than this
+	// many rounds behind the network's current expected round.
+	MaxRoundStaleness uint64
+
+	// Algorithms resolves each share's declared Algorithm to the
+	// HashAlgorithm that verifies it, so this engine isn't hardcoded to
+	// one proof-of-work scheme.
+	Algorithms *AlgorithmRegistry
 }
 
-// NewValidator cria uma instância do validador.
-// Em DEV (ENV=development), ativa modo leniente.
-func NewValidator() *ValidatorEngine {
+// NewValidator cria uma instância do validador a partir de registry.
+// Em DEV (ENV=development), ativa modo leniente. registry is typically
+// DefaultAlgorithmRegistry(), but callers running a single-coin pool can
+// pass a registry with just that algorithm registered.
+func NewValidator(registry *AlgorithmRegistry) *ValidatorEngine {
 	dev := strings.EqualFold(os.Getenv("ENV"), "development")
+	if registry == nil {
+		registry = DefaultAlgorithmRegistry()
+	}
 	return &ValidatorEngine{
 		MinLatencyMS: 100,
 		TargetHex:    "00000fffffffffffffffffffffffffffffffffffffffffffffffffffffffffff",
 		DevMode:      dev,
+		Algorithms:   registry,
 	}
 }
 
+// NewBeaconValidator builds a validator whose effective target per share
+// is derived from a drand beacon round instead of a static TargetHex,
+// so the target can't be predicted before that round is published.
+// networks need not be pre-sorted; NewBeaconValidator orders them by
+// StartRound itself.
+func NewBeaconValidator(registry *AlgorithmRegistry, networks []BeaconNetwork, maxRoundStaleness uint64) *ValidatorEngine {
+	v := NewValidator(registry)
+	sortBeaconNetworks(networks)
+	v.Beacons = networks
+	v.MaxRoundStaleness = maxRoundStaleness
+	return v
+}
+
 // ValidateShare realiza a validação técnica de um share.
 func (v *ValidatorEngine) ValidateShare(share types.Share) ValidationResult {
 	start := time.Now()
@@ -54,7 +93,31 @@ func (v *ValidatorEngine) ValidateShare(share types.Share) ValidationResult {
 		}
 	}
 
-	hash := v.computeHash(share.BlockTemplate, share.Nonce)
+	algoName := share.Algorithm
+	if algoName == "" {
+		algoName = defaultAlgorithm
+	}
+	algo, ok := v.Algorithms.Get(algoName)
+	if !ok {
+		return ValidationResult{
+			IsValid:      false,
+			ErrorReason:  fmt.Sprintf("algoritmo não suportado: %s", algoName),
+			ComputedHash: "",
+			LatencyMS:    time.Since(start).Milliseconds(),
+			Suspicious:   true,
+		}
+	}
+
+	hash, err := algo.Hash(share.BlockTemplate, share.Nonce)
+	if err != nil {
+		return ValidationResult{
+			IsValid:      false,
+			ErrorReason:  err.Error(),
+			ComputedHash: "",
+			LatencyMS:    time.Since(start).Milliseconds(),
+			Suspicious:   true,
+		}
+	}
 	latency := time.Since(start).Milliseconds()
 
 	// --- DEV MODE: aceitar formato/shape mínimo para destravar integração ---
@@ -73,7 +136,36 @@ func (v *ValidatorEngine) ValidateShare(share types.Share) ValidationResult {
 		// se por algum motivo não for hex-256, cai para as validações padrão para erro claro
 	}
 
-	valid := v.hashBelowTarget(hash)
+	target := v.TargetHex
+	if len(v.Beacons) > 0 {
+		t, err := v.beaconTarget(context.Background(), share.Round)
+		if err != nil {
+			result := ValidationResult{
+				IsValid:      false,
+				ErrorReason:  err.Error(),
+				ComputedHash: hash,
+				LatencyMS:    latency,
+				Suspicious:   true,
+			}
+			v.logResult(share, result)
+			return result
+		}
+		target = t
+	}
+
+	valid, err := compareHexTarget(hash, target)
+	if err != nil {
+		result := ValidationResult{
+			IsValid:      false,
+			ErrorReason:  err.Error(),
+			ComputedHash: hash,
+			LatencyMS:    latency,
+			Suspicious:   true,
+		}
+		v.logResult(share, result)
+		return result
+	}
+
 	result := ValidationResult{
 		IsValid:      valid,
 		ErrorReason:  "",
@@ -90,23 +182,31 @@ func (v *ValidatorEngine) ValidateShare(share types.Share) ValidationResult {
 	return result
 }
 
-// computeHash concatena dados e retorna o hash em hexadecimal.
-func (v *ValidatorEngine) computeHash(data, nonce string) string {
-	raw := data + nonce
-	sum := sha256.Sum256([]byte(raw))
-	return hex.EncodeToString(sum[:])
-}
+// beaconTarget resolves the drand-derived target for round: it picks
+// the beacon network responsible for round, rejects rounds too far
+// behind that network's current expected round, fetches (or reuses a
+// cached) beacon entry, and mixes it into TargetHex.
+func (v *ValidatorEngine) beaconTarget(ctx context.Context, round uint64) (string, error) {
+	if round == 0 {
+		return "", fmt.Errorf("round obrigatório no modo beacon")
+	}
 
-// hashBelowTarget verifica se o hash gerado está abaixo do target.
-func (v *ValidatorEngine) hashBelowTarget(hash string) bool {
-	if !isHex256(hash) {
-		return false
+	network, ok := networkFor(v.Beacons, round)
+	if !ok {
+		return "", fmt.Errorf("nenhuma rede de beacon configurada para o round %d", round)
 	}
-	h := new(big.Int)
-	t := new(big.Int)
-	h.SetString(hash, 16)
-	t.SetString(v.TargetHex, 16)
-	return h.Cmp(t) == -1
+
+	expected := network.expectedRound(time.Now())
+	if expected > round && expected-round > v.MaxRoundStaleness {
+		return "", fmt.Errorf("round %d muito antigo (esperado ~%d)", round, expected)
+	}
+
+	beacon, err := network.Source.Entry(ctx, round)
+	if err != nil {
+		return "", fmt.Errorf("falha ao obter beacon do round %d: %w", round, err)
+	}
+
+	return deriveBeaconTarget(v.TargetHex, beacon, round)
 }
 
 func isHex256(s string) bool {
@@ -19,7 +19,7 @@ type Handler struct {
 
 func NewHandler(tokens map[string]string, origins []string) *Handler {
 	return &Handler{
-		validator: core.NewValidator(),
+		validator: core.NewValidator(core.DefaultAlgorithmRegistry()),
 		auth:      security.NewTokenVerifier(tokens, origins),
 	}
 }
@@ -34,6 +34,10 @@ type shareValidationResponse struct {
 
 // ServeHTTP implementa a interface http.Handler
 func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if h.auth.HandlePreflight(w, r) {
+		return
+	}
+
 	switch {
 	case r.Method == http.MethodPost && r.URL.Path == "/validate/share":
 		h.HandleShare(w, r)
@@ -56,7 +60,7 @@ func (h *Handler) HandleShare(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	authMeta, err := h.auth.AuthenticateRequest(r)
+	authMeta, err := h.auth.AuthenticateRequest(w, r)
 	if err != nil {
 		http.Error(w, "Unauthorized", http.StatusUnauthorized)
 		logs.Warn("Rejected share: "+err.Error(), nil)
@@ -20,20 +20,29 @@ type AuthMetadata struct {
 
 // TokenVerifier realiza a verificação de tokens e origens permitidas.
 type TokenVerifier struct {
-	ValidTokens    map[string]string // Exemplo: map[token]role
-	AllowedOrigins []string          // Exemplo: ["https://meusite.com"]
+	ValidTokens map[string]string // Exemplo: map[token]role
+	CORS        *CORSPolicy
 }
 
-// NewTokenVerifier cria um verificador configurado.
+// NewTokenVerifier cria um verificador configurado. origins accepts the
+// syntaxes documented on CORSPolicy (exact, "*.sub" wildcard, "regex:").
+// Invalid entries fall back to a policy that allows nothing, since a
+// misconfigured allowlist should fail closed rather than open.
 func NewTokenVerifier(tokens map[string]string, origins []string) *TokenVerifier {
+	cors, err := NewCORSPolicy(origins, false)
+	if err != nil {
+		logs.Error("CORS POLICY INVÁLIDA", map[string]interface{}{"error": err.Error()})
+		cors, _ = NewCORSPolicy(nil, false)
+	}
 	return &TokenVerifier{
-		ValidTokens:    tokens,
-		AllowedOrigins: origins,
+		ValidTokens: tokens,
+		CORS:        cors,
 	}
 }
 
-// AuthenticateRequest valida o token e a origem.
-func (tv *TokenVerifier) AuthenticateRequest(r *http.Request) (*AuthMetadata, error) {
+// AuthenticateRequest valida o token e a origem, e grava os headers
+// Access-Control-* correspondentes na resposta quando a origem é permitida.
+func (tv *TokenVerifier) AuthenticateRequest(w http.ResponseWriter, r *http.Request) (*AuthMetadata, error) {
 	auth := r.Header.Get("Authorization")
 	token := strings.TrimPrefix(auth, "Bearer ")
 
@@ -52,7 +61,7 @@ func (tv *TokenVerifier) AuthenticateRequest(r *http.Request) (*AuthMetadata, er
 	}
 
 	origin := r.Header.Get("Origin")
-	if !tv.isAllowedOrigin(origin) {
+	if !tv.CORS.ApplyHeaders(w.Header(), origin) {
 		logs.Warn("ORIGEM BLOQUEADA", map[string]interface{}{
 			"origin": origin,
 		})
@@ -76,15 +85,23 @@ func (tv *TokenVerifier) AuthenticateRequest(r *http.Request) (*AuthMetadata, er
 	}, nil
 }
 
-// isAllowedOrigin verifica se a origem está autorizada.
-func (tv *TokenVerifier) isAllowedOrigin(origin string) bool {
-	origin = strings.ToLower(origin)
-	for _, allowed := range tv.AllowedOrigins {
-		if strings.Contains(origin, allowed) {
-			return true
-		}
+// HandlePreflight answers a CORS preflight (OPTIONS) request, writing
+// the appropriate Access-Control-* headers and a 204 when origin is
+// allowed, or a 403 otherwise. It reports whether r was a preflight
+// request, so callers know whether to stop processing it further.
+func (tv *TokenVerifier) HandlePreflight(w http.ResponseWriter, r *http.Request) bool {
+	if r.Method != http.MethodOptions {
+		return false
+	}
+
+	origin := r.Header.Get("Origin")
+	if !tv.CORS.ApplyHeaders(w.Header(), origin) {
+		logs.Warn("ORIGEM BLOQUEADA (preflight)", map[string]interface{}{"origin": origin})
+		w.WriteHeader(http.StatusForbidden)
+		return true
 	}
-	return false
+	w.WriteHeader(http.StatusNoContent)
+	return true
 }
 
 // extractClientIP retorna o IP real do cliente.
@@ -0,0 +1,67 @@
+package security
+
+import "testing"
+
+func TestCORSPolicySubstringBypass(t *testing.T) {
+	policy, err := NewCORSPolicy([]string{"https://meusite.com"}, false)
+	if err != nil {
+		t.Fatalf("NewCORSPolicy: %v", err)
+	}
+
+	bypassAttempts := []string{
+		"https://evil-meusite.com.attacker.net",
+		"https://meusite.com.attacker.net",
+		"https://attacker.net/meusite.com",
+		"https://notmeusite.com",
+	}
+	for _, origin := range bypassAttempts {
+		if policy.Allowed(origin) {
+			t.Errorf("Allowed(%q) = true, want false (substring bypass)", origin)
+		}
+	}
+
+	if !policy.Allowed("https://meusite.com") {
+		t.Errorf("Allowed(%q) = false, want true", "https://meusite.com")
+	}
+}
+
+func TestCORSPolicyWildcardSubdomain(t *testing.T) {
+	policy, err := NewCORSPolicy([]string{"https://*.example.com"}, false)
+	if err != nil {
+		t.Fatalf("NewCORSPolicy: %v", err)
+	}
+
+	allowed := []string{"https://api.example.com", "https://example.com"}
+	for _, origin := range allowed {
+		if !policy.Allowed(origin) {
+			t.Errorf("Allowed(%q) = false, want true", origin)
+		}
+	}
+
+	denied := []string{"https://example.com.attacker.net", "https://evil.com"}
+	for _, origin := range denied {
+		if policy.Allowed(origin) {
+			t.Errorf("Allowed(%q) = true, want false", origin)
+		}
+	}
+}
+
+func TestCORSPolicyRegex(t *testing.T) {
+	policy, err := NewCORSPolicy([]string{`regex:^https://[a-z0-9-]+\.internal\.example\.com$`}, false)
+	if err != nil {
+		t.Fatalf("NewCORSPolicy: %v", err)
+	}
+
+	if !policy.Allowed("https://staging.internal.example.com") {
+		t.Errorf("expected regex rule to allow staging.internal.example.com")
+	}
+	if policy.Allowed("https://staging.internal.example.com.attacker.net") {
+		t.Errorf("regex rule must anchor the full origin, not match as a prefix")
+	}
+}
+
+func TestCORSPolicyInvalidEntryFailsClosed(t *testing.T) {
+	if _, err := NewCORSPolicy([]string{"regex:("}, false); err == nil {
+		t.Fatalf("expected an error for an invalid regex entry")
+	}
+}
@@ -0,0 +1,94 @@
+package security
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RevocationStore tracks token IDs (JTI) that have been revoked before
+// their natural expiry, e.g. on logout or credential compromise.
+type RevocationStore interface {
+	IsRevoked(jti string) (bool, error)
+	Revoke(jti string, ttl time.Duration) error
+}
+
+// InMemoryRevocationStore keeps revoked JTIs in a map, each expiring on
+// its own so the set doesn't grow unbounded. Suitable for a single
+// instance; use RedisRevocationStore when validation is load-balanced
+// across multiple pool nodes.
+type InMemoryRevocationStore struct {
+	mu      sync.Mutex
+	entries map[string]time.Time
+}
+
+// NewInMemoryRevocationStore builds an empty store.
+func NewInMemoryRevocationStore() *InMemoryRevocationStore {
+	return &InMemoryRevocationStore{entries: make(map[string]time.Time)}
+}
+
+// IsRevoked reports whether jti was revoked and hasn't expired yet,
+// lazily dropping expired entries as they're encountered.
+func (s *InMemoryRevocationStore) IsRevoked(jti string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	expiresAt, ok := s.entries[jti]
+	if !ok {
+		return false, nil
+	}
+	if time.Now().After(expiresAt) {
+		delete(s.entries, jti)
+		return false, nil
+	}
+	return true, nil
+}
+
+// Revoke marks jti as revoked for ttl (normally the remaining lifetime
+// of the token it belongs to).
+func (s *InMemoryRevocationStore) Revoke(jti string, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	for k, expiresAt := range s.entries {
+		if now.After(expiresAt) {
+			delete(s.entries, k)
+		}
+	}
+	s.entries[jti] = now.Add(ttl)
+	return nil
+}
+
+// RedisRevocationStore shares revocation state across every pool node
+// validating tokens, so revoking a JTI on one node takes effect
+// everywhere immediately.
+type RedisRevocationStore struct {
+	client *redis.Client
+	prefix string
+}
+
+// NewRedisRevocationStore wraps an existing Redis client. keyPrefix is
+// prepended to every JTI to namespace the keyspace (e.g. "revoked:jti:").
+func NewRedisRevocationStore(client *redis.Client, keyPrefix string) *RedisRevocationStore {
+	if keyPrefix == "" {
+		keyPrefix = "revoked:jti:"
+	}
+	return &RedisRevocationStore{client: client, prefix: keyPrefix}
+}
+
+func (s *RedisRevocationStore) IsRevoked(jti string) (bool, error) {
+	n, err := s.client.Exists(context.Background(), s.prefix+jti).Result()
+	if err != nil {
+		return false, fmt.Errorf("revocation: redis lookup failed: %w", err)
+	}
+	return n > 0, nil
+}
+
+func (s *RedisRevocationStore) Revoke(jti string, ttl time.Duration) error {
+	if err := s.client.Set(context.Background(), s.prefix+jti, "1", ttl).Err(); err != nil {
+		return fmt.Errorf("revocation: redis write failed: %w", err)
+	}
+	return nil
+}
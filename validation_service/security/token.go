@@ -1,11 +1,14 @@
 package security
 
 import (
+	"crypto/ecdsa"
 	"crypto/rsa"
 	"crypto/x509"
 	"encoding/pem"
 	"errors"
+	"fmt"
 	"io/ioutil"
+	"sync"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -18,39 +21,137 @@ type Claims struct {
 	jwt.RegisteredClaims
 }
 
+// signingKey pairs a private key with the JWT method used to sign with
+// it, so TokenManager can hold both RS256 and ES256 keys side by side.
+type signingKey struct {
+	method  jwt.SigningMethod
+	private interface{}
+}
+
+// TokenManager issues and verifies miner auth tokens. Verification keys
+// come from a KeySource (a JWKS file or endpoint) selected by the token's
+// "kid" header, so keys can be rotated without restarting the service;
+// signing keys are held separately and likewise selected by kid, letting
+// an operator start signing with a new key while old tokens still
+// verify against the previous one until it's dropped from the JWKS.
 type TokenManager struct {
-	privateKey *rsa.PrivateKey
-	publicKey  *rsa.PublicKey
-	keyID      string
-	issuer     string
-	audience   string
-	ttl        time.Duration
+	keys       KeySource
+	revocation RevocationStore
+
+	signingMu   sync.RWMutex
+	signingKeys map[string]signingKey
+	activeKID   string
+
+	issuer   string
+	audience string
+	ttl      time.Duration
+}
+
+// NewTokenManager builds a TokenManager that verifies tokens against
+// keys, consulting revocation (may be nil to disable revocation checks)
+// for every validated token.
+func NewTokenManager(keys KeySource, revocation RevocationStore, issuer, audience string, ttl time.Duration) *TokenManager {
+	return &TokenManager{
+		keys:        keys,
+		revocation:  revocation,
+		signingKeys: make(map[string]signingKey),
+		issuer:      issuer,
+		audience:    audience,
+		ttl:         ttl,
+	}
 }
 
-func NewTokenManager(privateKeyPath, publicKeyPath, keyID, issuer, audience string, ttl time.Duration) (*TokenManager, error) {
-	priv, err := loadPrivateKey(privateKeyPath)
+// NewTokenManagerFromKeyFiles is a convenience constructor for the
+// common single-keypair deployment: it loads an RSA keypair from disk,
+// registers it as the active signing key, and serves it back out of a
+// static, non-rotating KeySource.
+func NewTokenManagerFromKeyFiles(privateKeyPath, publicKeyPath, keyID, issuer, audience string, ttl time.Duration) (*TokenManager, error) {
+	priv, err := loadRSAPrivateKey(privateKeyPath)
 	if err != nil {
 		return nil, err
 	}
-
 	pub, err := loadPublicKey(publicKeyPath)
 	if err != nil {
 		return nil, err
 	}
 
-	return &TokenManager{
-		privateKey: priv,
-		publicKey:  pub,
-		keyID:      keyID,
-		issuer:     issuer,
-		audience:   audience,
-		ttl:        ttl,
-	}, nil
+	tm := NewTokenManager(newStaticKeySource(keyID, pub), nil, issuer, audience, ttl)
+	if err := tm.registerSigningKey(keyID, jwt.SigningMethodRS256, priv); err != nil {
+		return nil, err
+	}
+	return tm, nil
 }
 
-func (tm *TokenManager) Generate(subject string, scopes []string, jti string) (string, error) {
-	now := time.Now().UTC()
+// AddSigningKeyFromFile loads a PEM-encoded RSA or EC private key from
+// path, registers it under kid, and makes it the active signing key so
+// an operator can rotate the key Generate uses without restarting the
+// service (old tokens keep validating as long as their kid stays in the
+// JWKS).
+func (tm *TokenManager) AddSigningKeyFromFile(kid, path string) error {
+	keyBytes, err := ioutil.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("token: failed to read signing key %s: %w", path, err)
+	}
+	block, _ := pem.Decode(keyBytes)
+	if block == nil {
+		return fmt.Errorf("token: invalid PEM in %s", path)
+	}
+
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		priv, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+		if err != nil {
+			return fmt.Errorf("token: invalid RSA private key in %s: %w", path, err)
+		}
+		return tm.registerSigningKey(kid, jwt.SigningMethodRS256, priv)
+	case "EC PRIVATE KEY":
+		priv, err := x509.ParseECPrivateKey(block.Bytes)
+		if err != nil {
+			return fmt.Errorf("token: invalid EC private key in %s: %w", path, err)
+		}
+		return tm.registerSigningKey(kid, jwt.SigningMethodES256, priv)
+	default:
+		return fmt.Errorf("token: unsupported private key type %q in %s", block.Type, path)
+	}
+}
+
+func (tm *TokenManager) registerSigningKey(kid string, method jwt.SigningMethod, private interface{}) error {
+	if kid == "" {
+		return errors.New("token: kid must not be empty")
+	}
+	tm.signingMu.Lock()
+	defer tm.signingMu.Unlock()
+	tm.signingKeys[kid] = signingKey{method: method, private: private}
+	tm.activeKID = kid
+	return nil
+}
+
+// SetActiveSigningKey switches which already-registered kid Generate
+// signs new tokens with, without touching the other registered keys.
+func (tm *TokenManager) SetActiveSigningKey(kid string) error {
+	tm.signingMu.Lock()
+	defer tm.signingMu.Unlock()
+	if _, ok := tm.signingKeys[kid]; !ok {
+		return fmt.Errorf("token: unknown signing kid %q", kid)
+	}
+	tm.activeKID = kid
+	return nil
+}
+
+// Generate signs a new token with the signing key registered under kid,
+// or the active signing key if kid is empty.
+func (tm *TokenManager) Generate(subject string, scopes []string, jti string, kid string) (string, error) {
+	tm.signingMu.RLock()
+	if kid == "" {
+		kid = tm.activeKID
+	}
+	key, ok := tm.signingKeys[kid]
+	tm.signingMu.RUnlock()
+	if !ok {
+		return "", fmt.Errorf("token: unknown signing kid %q", kid)
+	}
 
+	now := time.Now().UTC()
 	claims := Claims{
 		Sub:    subject,
 		Scopes: scopes,
@@ -64,20 +165,27 @@ func (tm *TokenManager) Generate(subject string, scopes []string, jti string) (s
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
-	token.Header["kid"] = tm.keyID
-
-	return token.SignedString(tm.privateKey)
+	token := jwt.NewWithClaims(key.method, claims)
+	token.Header["kid"] = kid
+	return token.SignedString(key.private)
 }
 
 func (tm *TokenManager) Validate(tokenString string) (*Claims, error) {
 	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodRSA); !ok {
+		kid, _ := token.Header["kid"].(string)
+		if kid == "" {
+			return nil, errors.New("token missing kid header")
+		}
+
+		switch token.Method.(type) {
+		case *jwt.SigningMethodRSA:
+		case *jwt.SigningMethodECDSA:
+		default:
 			return nil, errors.New("unexpected signing method")
 		}
-		return tm.publicKey, nil
-	})
 
+		return tm.keys.Key(kid)
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -87,13 +195,33 @@ func (tm *TokenManager) Validate(tokenString string) (*Claims, error) {
 		return nil, errors.New("invalid token or claims")
 	}
 
-	// Future: check blacklist for revoked tokens using claims.JTI
-	// if revoked(claims.JTI) { return nil, errors.New("token revoked") }
+	if tm.revocation != nil {
+		revoked, err := tm.revocation.IsRevoked(claims.JTI)
+		if err != nil {
+			return nil, fmt.Errorf("token: revocation check failed: %w", err)
+		}
+		if revoked {
+			return nil, errors.New("token revoked")
+		}
+	}
 
 	return claims, nil
 }
 
-func loadPrivateKey(path string) (*rsa.PrivateKey, error) {
+// Revoke marks a token's JTI as revoked for the rest of its natural
+// lifetime, e.g. on logout. No-op if the manager has no RevocationStore.
+func (tm *TokenManager) Revoke(claims *Claims) error {
+	if tm.revocation == nil {
+		return nil
+	}
+	ttl := time.Until(claims.ExpiresAt.Time)
+	if ttl <= 0 {
+		return nil
+	}
+	return tm.revocation.Revoke(claims.JTI, ttl)
+}
+
+func loadRSAPrivateKey(path string) (*rsa.PrivateKey, error) {
 	keyBytes, err := ioutil.ReadFile(path)
 	if err != nil {
 		return nil, err
@@ -107,7 +235,9 @@ func loadPrivateKey(path string) (*rsa.PrivateKey, error) {
 	return x509.ParsePKCS1PrivateKey(block.Bytes)
 }
 
-func loadPublicKey(path string) (*rsa.PublicKey, error) {
+// loadPublicKey reads an RSA or EC public key from a PEM file, for use
+// with NewTokenManagerFromKeyFiles's static single-key KeySource.
+func loadPublicKey(path string) (interface{}, error) {
 	keyBytes, err := ioutil.ReadFile(path)
 	if err != nil {
 		return nil, err
@@ -123,12 +253,14 @@ func loadPublicKey(path string) (*rsa.PublicKey, error) {
 		return nil, err
 	}
 
-	pub, ok := pubInterface.(*rsa.PublicKey)
-	if !ok {
-		return nil, errors.New("not an RSA public key")
+	switch pub := pubInterface.(type) {
+	case *rsa.PublicKey:
+		return pub, nil
+	case *ecdsa.PublicKey:
+		return pub, nil
+	default:
+		return nil, errors.New("unsupported public key type")
 	}
-
-	return pub, nil
 }
 
 func RequireScope(claims *Claims, required string) bool {
@@ -18,6 +18,15 @@ type Share struct {
 	IP            net.IP    `json:"ip,omitempty"`
 	GPUModel      string    `json:"gpu_model,omitempty"`
 	GPUID         string    `json:"gpu_id,omitempty"`
+
+	// Round is the drand round the miner claims this share's target was
+	// derived from, required only when ValidatorEngine has a beacon mode
+	// configured; zero otherwise.
+	Round uint64 `json:"round,omitempty"`
+
+	// Algorithm selects which core.HashAlgorithm verifies this share
+	// (e.g. "sha256d", "scrypt", "ethash"); empty defaults to "sha256d".
+	Algorithm string `json:"algorithm,omitempty"`
 }
 
 const (